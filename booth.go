@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	g "xabbo.b7c.io/goearth"
+)
+
+// roomObject is a dice furni seen in the room's active objects
+type roomObject struct {
+	ID    int
+	Class string
+	X     int
+	Y     int
+}
+
+// Dice furni in the current room by id, from the active object packets
+var roomDice = map[int]roomObject{}
+
+// BoothDice is one dice of a proposed booth
+type BoothDice struct {
+	ID int `json:"id"`
+	X  int `json:"x"`
+	Y  int `json:"y"`
+}
+
+// BoothProposal is a set of dice the dealer can reach standing on one tile
+type BoothProposal struct {
+	X    int         `json:"x"`
+	Y    int         `json:"y"`
+	Dice []BoothDice `json:"dice"`
+}
+
+// Handle the ACTIVE_OBJECTS packet (the furni list sent on room entry)
+func (a *App) handleActiveObjects(e *g.Intercept) {
+	r := newWireReader(e.Packet.Data)
+	n, err := r.readInt()
+	if err != nil {
+		return
+	}
+
+	found := map[int]roomObject{}
+	for i := 0; i < n; i++ {
+		obj, err := readRoomObject(r)
+		if err != nil {
+			break
+		}
+		if isDiceClass(obj.Class) {
+			found[obj.ID] = obj
+		}
+	}
+
+	mutex.Lock()
+	roomDice = found
+	mutex.Unlock()
+
+	if len(found) > 0 {
+		a.AddLogMsg(fmt.Sprintf("Found %d dice in the room, confirm the booth in Booth Setup", len(found)))
+	}
+	a.emitBoothUpdate()
+}
+
+// Handle ACTIVEOBJECT_ADD and ACTIVEOBJECT_UPDATE (furni placed or moved)
+func (a *App) handleActiveObjectUpdate(e *g.Intercept) {
+	obj, err := readRoomObject(newWireReader(e.Packet.Data))
+	if err != nil || !isDiceClass(obj.Class) {
+		return
+	}
+
+	mutex.Lock()
+	roomDice[obj.ID] = obj
+	mutex.Unlock()
+	if table.MoveDice(obj.ID, obj.X, obj.Y) {
+		a.saveCurrentBooth()
+	}
+	a.emitBoothUpdate()
+}
+
+// Handle ACTIVEOBJECT_REMOVE (furni picked up)
+func (a *App) handleActiveObjectRemove(e *g.Intercept) {
+	idStr, err := newWireReader(e.Packet.Data).readString()
+	if err != nil {
+		return
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return
+	}
+
+	mutex.Lock()
+	_, known := roomDice[id]
+	delete(roomDice, id)
+	mutex.Unlock()
+	if known {
+		a.emitBoothUpdate()
+	}
+}
+
+// readRoomObject reads a single active object: id, class, x, y, width,
+// length, direction, z, colors, runtime data, extra and stuff data.
+func readRoomObject(r *wireReader) (roomObject, error) {
+	var obj roomObject
+	idStr, err := r.readString()
+	if err != nil {
+		return obj, err
+	}
+	if obj.ID, err = strconv.Atoi(idStr); err != nil {
+		return obj, err
+	}
+	if obj.Class, err = r.readString(); err != nil {
+		return obj, err
+	}
+	if obj.X, err = r.readInt(); err != nil {
+		return obj, err
+	}
+	if obj.Y, err = r.readInt(); err != nil {
+		return obj, err
+	}
+	// width, length, direction
+	for i := 0; i < 3; i++ {
+		if _, err = r.readInt(); err != nil {
+			return obj, err
+		}
+	}
+	// z, colors, runtime data
+	for i := 0; i < 3; i++ {
+		if _, err = r.readString(); err != nil {
+			return obj, err
+		}
+	}
+	// extra
+	if _, err = r.readInt(); err != nil {
+		return obj, err
+	}
+	// stuff data
+	if _, err = r.readString(); err != nil {
+		return obj, err
+	}
+	return obj, nil
+}
+
+// newBoothDice returns a dice with its tile filled in when it was seen in
+// the room objects
+func newBoothDice(id int, closed bool) *Dice {
+	dice := &Dice{ID: id, IsClosed: closed}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if obj, ok := roomDice[id]; ok {
+		dice.X, dice.Y, dice.Placed = obj.X, obj.Y, true
+	}
+	return dice
+}
+
+func isDiceClass(class string) bool {
+	return strings.Contains(strings.ToLower(class), "dice")
+}
+
+func (a *App) emitBoothUpdate() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "boothUpdate")
+	}
+}
+
+// proposeBooths lists the tiles next to room dice, with the dice reachable
+// from each. Dice can only be thrown from an adjacent tile, so a booth is
+// every dice around the tile the dealer stands on. Tiles whose dice are all
+// reachable from a better tile are left out.
+func proposeBooths(dice map[int]roomObject) []BoothProposal {
+	type tile struct{ x, y int }
+	occupied := map[tile]bool{}
+	for _, d := range dice {
+		occupied[tile{d.X, d.Y}] = true
+	}
+
+	reach := map[tile][]BoothDice{}
+	for _, d := range dice {
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				t := tile{d.X + dx, d.Y + dy}
+				if occupied[t] {
+					continue
+				}
+				reach[t] = append(reach[t], BoothDice{ID: d.ID, X: d.X, Y: d.Y})
+			}
+		}
+	}
+
+	proposals := make([]BoothProposal, 0, len(reach))
+	for t, reachable := range reach {
+		sort.Slice(reachable, func(i, j int) bool {
+			if reachable[i].Y != reachable[j].Y {
+				return reachable[i].Y < reachable[j].Y
+			}
+			return reachable[i].X < reachable[j].X
+		})
+		proposals = append(proposals, BoothProposal{X: t.x, Y: t.y, Dice: reachable})
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		if len(proposals[i].Dice) != len(proposals[j].Dice) {
+			return len(proposals[i].Dice) > len(proposals[j].Dice)
+		}
+		if proposals[i].Y != proposals[j].Y {
+			return proposals[i].Y < proposals[j].Y
+		}
+		return proposals[i].X < proposals[j].X
+	})
+
+	// Drop proposals covered by a bigger (or equal, earlier) one
+	kept := []BoothProposal{}
+	for _, p := range proposals {
+		covered := false
+		for _, k := range kept {
+			if containsAllDice(k.Dice, p.Dice) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func containsAllDice(set []BoothDice, subset []BoothDice) bool {
+	ids := map[int]bool{}
+	for _, d := range set {
+		ids[d.ID] = true
+	}
+	for _, d := range subset {
+		if !ids[d.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetBoothProposals returns the booths found in the current room, best first
+func (a *App) GetBoothProposals() []BoothProposal {
+	mutex.Lock()
+	dice := make(map[int]roomObject, len(roomDice))
+	for id, d := range roomDice {
+		dice[id] = d
+	}
+	mutex.Unlock()
+	return proposeBooths(dice)
+}
+
+// ConfirmBooth replaces the booth with the given dice, in the given order
+func (a *App) ConfirmBooth(ids []int) bool {
+	if len(ids) == 0 || len(ids) > maxBoothDice {
+		a.AddLogMsg(fmt.Sprintf("Booth not set: pick between 1 and %d dice", maxBoothDice))
+		return false
+	}
+
+	mutex.Lock()
+	dices := make([]*Dice, 0, len(ids))
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			mutex.Unlock()
+			a.AddLogMsg(fmt.Sprintf("Booth not set: dice %d is listed twice", id))
+			return false
+		}
+		seen[id] = true
+		obj, ok := roomDice[id]
+		if !ok {
+			mutex.Unlock()
+			a.AddLogMsg(fmt.Sprintf("Booth not set: dice %d is no longer in the room", id))
+			return false
+		}
+		dices = append(dices, &Dice{ID: id, X: obj.X, Y: obj.Y, Placed: true})
+	}
+	mutex.Unlock()
+
+	if err := table.SetBooth(dices); err != nil {
+		a.AddLogMsg("Booth not set: " + err.Error())
+		return false
+	}
+	a.saveCurrentBooth()
+	a.AddLogMsg(fmt.Sprintf("Booth set with %d dice! Run :roll to confirm", len(dices)))
+	return true
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	g "xabbo.b7c.io/goearth"
+)
+
+// SavedBooth is the booth the dealer used in a room
+type SavedBooth struct {
+	RoomID int         `json:"room_id"`
+	Dice   []BoothDice `json:"dice"`
+	Saved  string      `json:"saved"`
+}
+
+// Booths by room id, kept in booths.json
+var (
+	savedBooths   = map[int]SavedBooth{}
+	savedBoothsMu sync.Mutex
+)
+
+func getBoothsFilePath() string {
+	return configFilePath("booths.json")
+}
+
+func (a *App) loadSavedBooths() {
+	loaded := map[int]SavedBooth{}
+	file, err := os.Open(getBoothsFilePath())
+	if err == nil {
+		defer file.Close()
+		if err := json.NewDecoder(file).Decode(&loaded); err != nil {
+			a.AddLogMsg("Error decoding booths file: " + err.Error())
+			loaded = map[int]SavedBooth{}
+		}
+	}
+
+	savedBoothsMu.Lock()
+	savedBooths = loaded
+	savedBoothsMu.Unlock()
+}
+
+// writeSavedBooths saves the booths file, callers hold savedBoothsMu
+func writeSavedBooths() error {
+	file, err := os.Create(getBoothsFilePath())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(savedBooths)
+}
+
+// Handle ROOM_READY ("<model> <room id>"), sent when entering a room.
+// The booth saved for the room replaces the one from the last room.
+func (a *App) handleRoomReady(e *g.Intercept) {
+	fields := strings.Fields(e.Packet.ReadString())
+	if len(fields) < 2 {
+		return
+	}
+	roomID, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return
+	}
+
+	mutex.Lock()
+	currentRoomID = roomID
+	roomDice = map[int]roomObject{}
+	resetRoomUsers()
+	mutex.Unlock()
+	table.Reset()
+	if !handReady() {
+		a.refreshInventory("entered a room")
+	}
+
+	savedBoothsMu.Lock()
+	booth, ok := savedBooths[roomID]
+	savedBoothsMu.Unlock()
+	if !ok {
+		a.AddLogMsg(fmt.Sprintf("Entered room %d, no saved booth", roomID))
+		a.emitBoothUpdate()
+		return
+	}
+
+	dices := make([]*Dice, 0, len(booth.Dice))
+	for _, d := range booth.Dice {
+		dices = append(dices, &Dice{ID: d.ID, X: d.X, Y: d.Y, Placed: true})
+	}
+	if err := table.SetBooth(dices); err != nil {
+		a.AddLogMsg("Saved booth not restored: " + err.Error())
+		return
+	}
+	a.AddLogMsg(fmt.Sprintf("Entered room %d, restored booth with %d dice", roomID, len(dices)))
+	a.emitBoothUpdate()
+}
+
+// saveCurrentBooth stores the table's dice for the current room
+func (a *App) saveCurrentBooth() {
+	mutex.Lock()
+	roomID := currentRoomID
+	mutex.Unlock()
+	if roomID == 0 {
+		return
+	}
+
+	dices := table.Snapshot()
+	booth := SavedBooth{
+		RoomID: roomID,
+		Dice:   make([]BoothDice, 0, len(dices)),
+		Saved:  time.Now().Format("2006-01-02 15:04"),
+	}
+	for _, dice := range dices {
+		booth.Dice = append(booth.Dice, BoothDice{ID: dice.ID, X: dice.X, Y: dice.Y})
+	}
+
+	savedBoothsMu.Lock()
+	savedBooths[roomID] = booth
+	err := writeSavedBooths()
+	savedBoothsMu.Unlock()
+
+	if err != nil {
+		a.AddLogMsg("Error saving booth: " + err.Error())
+		return
+	}
+	a.emitBoothUpdate()
+}
+
+// forgetCurrentBooth removes the saved booth of the current room
+func (a *App) forgetCurrentBooth() {
+	mutex.Lock()
+	roomID := currentRoomID
+	mutex.Unlock()
+	if roomID != 0 {
+		a.DeleteSavedBooth(roomID)
+	}
+}
+
+// GetSavedBooths lists the saved booths by room id
+func (a *App) GetSavedBooths() []SavedBooth {
+	savedBoothsMu.Lock()
+	defer savedBoothsMu.Unlock()
+	booths := make([]SavedBooth, 0, len(savedBooths))
+	for _, booth := range savedBooths {
+		booths = append(booths, booth)
+	}
+	sort.Slice(booths, func(i, j int) bool { return booths[i].RoomID < booths[j].RoomID })
+	return booths
+}
+
+// DeleteSavedBooth forgets the booth saved for a room
+func (a *App) DeleteSavedBooth(roomID int) {
+	savedBoothsMu.Lock()
+	if _, ok := savedBooths[roomID]; !ok {
+		savedBoothsMu.Unlock()
+		return
+	}
+	delete(savedBooths, roomID)
+	err := writeSavedBooths()
+	savedBoothsMu.Unlock()
+
+	if err != nil {
+		a.AddLogMsg("Error saving booths file: " + err.Error())
+		return
+	}
+	a.AddLogMsg(fmt.Sprintf("Saved booth for room %d deleted", roomID))
+	a.emitBoothUpdate()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ItemValue is what one furni of a class is worth in credits. Bets and
+// balances are counted in credits so a bet can mix item classes.
+type ItemValue struct {
+	Class string `json:"class"`
+	Value int    `json:"value"`
+}
+
+// Credits per item class, from item_values.json
+var (
+	itemValues   = map[string]int{}
+	itemValuesMu sync.RWMutex
+)
+
+func getItemValuesFilePath() string {
+	return configFilePath("item_values.json")
+}
+
+func defaultItemValues() []ItemValue {
+	return []ItemValue{{Class: "duck", Value: 1}}
+}
+
+func (a *App) LoadItemValues() []ItemValue {
+	loaded := defaultItemValues()
+
+	file, err := os.Open(getItemValuesFilePath())
+	if err == nil {
+		defer file.Close()
+		if err := json.NewDecoder(file).Decode(&loaded); err != nil {
+			a.AddLogMsg("Error decoding item values file: " + err.Error())
+			loaded = defaultItemValues()
+		}
+	}
+	if err := validateItemValues(loaded); err != nil {
+		a.AddLogMsg("Item values reset: " + err.Error())
+		loaded = defaultItemValues()
+	}
+
+	setItemValues(loaded)
+	return loaded
+}
+
+func (a *App) SaveItemValues(values []ItemValue) {
+	for i := range values {
+		values[i].Class = strings.ToLower(strings.TrimSpace(values[i].Class))
+	}
+	if err := validateItemValues(values); err != nil {
+		a.AddLogMsg("Item values not saved: " + err.Error())
+		return
+	}
+
+	file, err := os.Create(getItemValuesFilePath())
+	if err != nil {
+		a.AddLogMsg("Error creating item values file: " + err.Error())
+		return
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(values); err != nil {
+		a.AddLogMsg("Error encoding item values file: " + err.Error())
+		return
+	}
+
+	setItemValues(values)
+	a.AddLogMsg("Item values saved successfully")
+	a.emitLedgerUpdate()
+}
+
+func validateItemValues(values []ItemValue) error {
+	seen := map[string]bool{}
+	for _, v := range values {
+		if v.Class == "" {
+			return fmt.Errorf("item class can't be empty")
+		}
+		if seen[v.Class] {
+			return fmt.Errorf("%s is listed twice", v.Class)
+		}
+		seen[v.Class] = true
+		if v.Value <= 0 {
+			return fmt.Errorf("%s must be worth at least 1 credit", v.Class)
+		}
+	}
+	return nil
+}
+
+func setItemValues(values []ItemValue) {
+	byClass := make(map[string]int, len(values))
+	for _, v := range values {
+		byClass[v.Class] = v.Value
+	}
+	itemValuesMu.Lock()
+	itemValues = byClass
+	itemValuesMu.Unlock()
+}
+
+func itemValue(class string) (int, bool) {
+	itemValuesMu.RLock()
+	defer itemValuesMu.RUnlock()
+	value, ok := itemValues[class]
+	return value, ok
+}
+
+// valueItems totals what trade items are worth in credits and counts them
+// by class. Classes without a value are returned so the bet can be refused.
+func valueItems(items []TradeItem) (total int, counts map[string]int, unknown []string) {
+	counts = map[string]int{}
+	for _, item := range items {
+		counts[item.Class]++
+		value, ok := itemValue(item.Class)
+		if !ok {
+			if counts[item.Class] == 1 {
+				unknown = append(unknown, item.Class)
+			}
+			continue
+		}
+		total += value
+	}
+	return total, counts, unknown
+}
+
+// describeItems lists item counts by class, like "2 duck, 1 throne"
+func describeItems(counts map[string]int) string {
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	parts := make([]string, 0, len(classes))
+	for _, class := range classes {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[class], class))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// planPayout picks how many items of each class pay exactly amount credits
+// from stock, using as few items as possible. ok is false when no
+// combination of the stock adds up to the amount.
+func planPayout(amount int, stock map[string]int) (counts map[string]int, ok bool) {
+	if amount < 0 {
+		return nil, false
+	}
+	type option struct {
+		class string
+		value int
+		have  int
+	}
+	options := []option{}
+	for class, have := range stock {
+		if value, known := itemValue(class); known && have > 0 {
+			options = append(options, option{class, value, have})
+		}
+	}
+	sort.Slice(options, func(i, j int) bool {
+		if options[i].value != options[j].value {
+			return options[i].value > options[j].value
+		}
+		return options[i].class < options[j].class
+	})
+
+	// fewest[v] is the fewest items adding up to v credits with the classes
+	// seen so far, used[i][v] how many of class i that took
+	const none = int(^uint(0) >> 1)
+	fewest := make([]int, amount+1)
+	for v := 1; v <= amount; v++ {
+		fewest[v] = none
+	}
+	used := make([][]int, len(options))
+	for i, opt := range options {
+		next := make([]int, amount+1)
+		used[i] = make([]int, amount+1)
+		for v := 0; v <= amount; v++ {
+			next[v] = none
+			for k := 0; k <= opt.have && k*opt.value <= v; k++ {
+				if prev := fewest[v-k*opt.value]; prev != none && prev+k < next[v] {
+					next[v] = prev + k
+					used[i][v] = k
+				}
+			}
+		}
+		fewest = next
+	}
+	if fewest[amount] == none {
+		return nil, false
+	}
+
+	counts = map[string]int{}
+	for i, v := len(options)-1, amount; i >= 0; i-- {
+		if k := used[i][v]; k > 0 {
+			counts[options[i].class] = k
+			v -= k * options[i].value
+		}
+	}
+	return counts, true
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanPayout(t *testing.T) {
+	itemValuesMu.RLock()
+	saved := itemValues
+	itemValuesMu.RUnlock()
+	defer func() {
+		itemValuesMu.Lock()
+		itemValues = saved
+		itemValuesMu.Unlock()
+	}()
+	setItemValues([]ItemValue{
+		{Class: "throne", Value: 50},
+		{Class: "sofa", Value: 30},
+		{Class: "chair", Value: 25},
+		{Class: "duck", Value: 10},
+	})
+
+	tests := []struct {
+		name   string
+		amount int
+		stock  map[string]int
+		want   map[string]int
+		wantOK bool
+	}{
+		{
+			name:   "exact sum",
+			amount: 60,
+			stock:  map[string]int{"throne": 1, "duck": 5},
+			want:   map[string]int{"throne": 1, "duck": 1},
+			wantOK: true,
+		},
+		{
+			name:   "fewest items, not the largest first",
+			amount: 50,
+			stock:  map[string]int{"sofa": 2, "chair": 2, "duck": 5},
+			want:   map[string]int{"chair": 2},
+			wantOK: true,
+		},
+		{
+			name:   "limited by stock",
+			amount: 100,
+			stock:  map[string]int{"throne": 1, "duck": 5},
+			want:   map[string]int{"throne": 1, "duck": 5},
+			wantOK: true,
+		},
+		{
+			name:   "impossible amount",
+			amount: 55,
+			stock:  map[string]int{"throne": 1, "duck": 5},
+			wantOK: false,
+		},
+		{
+			name:   "not enough stock",
+			amount: 200,
+			stock:  map[string]int{"throne": 1, "duck": 5},
+			wantOK: false,
+		},
+		{
+			name:   "classes without a value are left out",
+			amount: 10,
+			stock:  map[string]int{"lamp": 3},
+			wantOK: false,
+		},
+		{
+			name:   "zero",
+			amount: 0,
+			stock:  map[string]int{"throne": 1},
+			want:   map[string]int{},
+			wantOK: true,
+		},
+		{
+			name:   "negative",
+			amount: -10,
+			stock:  map[string]int{"duck": 5},
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := planPayout(tt.amount, tt.stock)
+			if ok != tt.wantOK {
+				t.Fatalf("planPayout(%d) ok = %t, want %t (got %v)", tt.amount, ok, tt.wantOK, got)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planPayout(%d) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+
+	"xabbo.b7c.io/goearth/shockwave/out"
+)
+
+// Chat channels a message can be sent on
+const (
+	ChannelShout   = "shout"
+	ChannelTalk    = "talk"
+	ChannelWhisper = "whisper"
+)
+
+// Message types, each sent on the channel set for it in the settings
+const (
+	// Hands and round results
+	MessageResults = "results"
+	// Session start, game choice, voids and languages
+	MessageSession = "session"
+	// Balance after a win, push or risk
+	MessageBalance = "balance"
+	// Refunds and cashouts
+	MessageCashout = "cashout"
+)
+
+var messageTypes = []string{MessageResults, MessageSession, MessageBalance, MessageCashout}
+
+// Type of every message template, the ones left out are session messages
+var messageTypeOf = map[string]string{
+	msgHandPlayer:  MessageResults,
+	msgHandDealer:  MessageResults,
+	msgHandSolo:    MessageResults,
+	msgPlayerWins:  MessageResults,
+	msgDealerWins:  MessageResults,
+	msgPlayerBusts: MessageResults,
+	msgDealerBusts: MessageResults,
+	msgTie:         MessageResults,
+	msgRollVoid:    MessageResults,
+	msgSessionWin:  MessageBalance,
+	msgSessionPush: MessageBalance,
+	msgRisk:        MessageBalance,
+	msgRefundDone:  MessageCashout,
+	msgCashoutDone: MessageCashout,
+}
+
+func defaultChannels() map[string]string {
+	return map[string]string{
+		MessageResults: ChannelShout,
+		MessageSession: ChannelShout,
+		MessageBalance: ChannelWhisper,
+		MessageCashout: ChannelWhisper,
+	}
+}
+
+func validChannel(channel string) bool {
+	return channel == ChannelShout || channel == ChannelTalk || channel == ChannelWhisper
+}
+
+// validateChannels checks every message type has a known channel
+func validateChannels(channels map[string]string) error {
+	for kind, channel := range channels {
+		if _, ok := defaultChannels()[kind]; !ok {
+			return fmt.Errorf("unknown message type %q", kind)
+		}
+		if !validChannel(channel) {
+			return fmt.Errorf("%s channel must be shout, talk or whisper", kind)
+		}
+	}
+	return nil
+}
+
+func messageType(key string) string {
+	if kind, ok := messageTypeOf[key]; ok {
+		return kind
+	}
+	return MessageSession
+}
+
+// routeChat addresses a message of a type. Whispers go to the player and
+// are shouted when there is nobody to whisper to.
+func routeChat(kind string, text string, player string, round int) queuedChat {
+	channel := currentSettings().Channels[kind]
+	if channel == "" {
+		channel = defaultChannels()[kind]
+	}
+	if channel == ChannelWhisper && player == "" {
+		channel = ChannelShout
+	}
+	msg := queuedChat{text: text, round: round, channel: channel}
+	if channel == ChannelWhisper {
+		msg.to = player
+	}
+	return msg
+}
+
+// sendChat says a message on its channel
+func sendChat(msg queuedChat) {
+	switch msg.channel {
+	case ChannelTalk:
+		ext.Send(out.CHAT, msg.text)
+	case ChannelWhisper:
+		ext.Send(out.WHISPER, msg.to+" "+msg.text)
+	default:
+		ext.Send(out.SHOUT, msg.text)
+	}
+}
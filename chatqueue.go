@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// queuedChat is a message waiting to be said on its channel, to is the
+// player a whisper goes to. Results carry the table round they belong to so
+// results of older rounds can be dropped once newer ones are waiting behind
+// them.
+type queuedChat struct {
+	text    string
+	round   int
+	channel string
+	to      string
+}
+
+// ChatQueue sends the dealer's chat in order, no faster than the configured
+// messages per window, and holds it while muted.
+type ChatQueue struct {
+	mu      sync.Mutex
+	pending []queuedChat
+	sent    []time.Time
+	wake    chan struct{}
+}
+
+var chatQueue = &ChatQueue{wake: make(chan struct{}, 1)}
+
+// Push queues a message. Its round is the table round of a game result, 0
+// for messages that must always be said.
+func (q *ChatQueue) Push(msg queuedChat) {
+	q.mu.Lock()
+	q.pending = append(q.pending, msg)
+	q.mu.Unlock()
+	q.Wake()
+}
+
+// Wake makes the queue look at its messages again, after a mute ends
+func (q *ChatQueue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of messages waiting
+func (q *ChatQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// coalesce drops queued results of rounds older than the latest queued one
+// and returns how many it dropped. It runs when a mute ends, so a backlog
+// that only waits on the rate limit is said in full.
+func (q *ChatQueue) coalesce() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	latest := 0
+	for _, msg := range q.pending {
+		if msg.round > latest {
+			latest = msg.round
+		}
+	}
+	kept := q.pending[:0]
+	for _, msg := range q.pending {
+		if msg.round == 0 || msg.round == latest {
+			kept = append(kept, msg)
+		}
+	}
+	dropped := len(q.pending) - len(kept)
+	q.pending = kept
+	return dropped
+}
+
+// next returns the message to send now, or how long to wait before one can
+// be sent. ok is false when there is nothing to send.
+func (q *ChatQueue) next(limit int, window time.Duration) (msg queuedChat, wait time.Duration, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	recent := q.sent[:0]
+	for _, at := range q.sent {
+		if now.Sub(at) < window {
+			recent = append(recent, at)
+		}
+	}
+	q.sent = recent
+
+	if len(q.pending) == 0 {
+		return msg, 0, false
+	}
+	if len(q.sent) >= limit {
+		return msg, window - now.Sub(q.sent[0]), false
+	}
+	msg = q.pending[0]
+	q.pending = q.pending[1:]
+	q.sent = append(q.sent, now)
+	return msg, 0, true
+}
+
+// runChatQueue sends queued chat for as long as the app runs
+func (a *App) runChatQueue() {
+	held := false
+	for {
+		if mute.Muted() {
+			held = true
+			a.emitChatQueue()
+			<-chatQueue.wake
+			continue
+		}
+		if held {
+			// Only the latest round's results are worth saying after a mute
+			held = false
+			if dropped := chatQueue.coalesce(); dropped > 0 {
+				a.AddLogMsg(fmt.Sprintf("Chat: dropped %d stale results held during the mute", dropped))
+			}
+		}
+
+		limits := currentSettings()
+		msg, wait, ok := chatQueue.next(limits.ChatLimit, time.Duration(limits.ChatWindow)*time.Second)
+		a.emitChatQueue()
+		if !ok {
+			if wait > 0 {
+				select {
+				case <-chatQueue.wake:
+				case <-time.After(wait):
+				}
+			} else {
+				<-chatQueue.wake
+			}
+			continue
+		}
+
+		activeTiming().ChatTyping.Sleep()
+		sendChat(msg)
+		log.Printf("Sent %s: %s", msg.channel, msg.text)
+	}
+}
+
+func (a *App) emitChatQueue() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "chatQueue", chatQueue.Len())
+	}
+}
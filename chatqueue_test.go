@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// drain takes every message the queue would send right now
+func drain(q *ChatQueue) []string {
+	var texts []string
+	for {
+		msg, _, ok := q.next(100, time.Minute)
+		if !ok {
+			return texts
+		}
+		texts = append(texts, msg.text)
+	}
+}
+
+func staleQueue() *ChatQueue {
+	return &ChatQueue{pending: []queuedChat{
+		{text: "round 1 result", round: 1},
+		{text: "bet traded in", round: 0},
+		{text: "round 2 result", round: 2},
+		{text: "round 1 payout", round: 1},
+		{text: "round 2 payout", round: 2},
+	}}
+}
+
+func TestChatQueueCoalesce(t *testing.T) {
+	q := staleQueue()
+	if dropped := q.coalesce(); dropped != 2 {
+		t.Errorf("coalesce() = %d, want 2", dropped)
+	}
+	want := []string{"bet traded in", "round 2 result", "round 2 payout"}
+	if got := drain(q); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+	if dropped := q.coalesce(); dropped != 0 {
+		t.Errorf("coalesce() on an empty queue = %d", dropped)
+	}
+}
+
+// Without a mute nothing is coalesced, a backlog behind the rate limit is
+// said in full
+func TestChatQueueKeepsBacklog(t *testing.T) {
+	q := staleQueue()
+	want := []string{"round 1 result", "bet traded in", "round 2 result", "round 1 payout", "round 2 payout"}
+	if got := drain(q); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestChatQueueRateLimit(t *testing.T) {
+	q := &ChatQueue{pending: []queuedChat{{text: "a"}, {text: "b"}, {text: "c"}}}
+
+	for _, want := range []string{"a", "b"} {
+		msg, _, ok := q.next(2, time.Minute)
+		if !ok || msg.text != want {
+			t.Fatalf("next() = %q, %t, want %q", msg.text, ok, want)
+		}
+	}
+	_, wait, ok := q.next(2, time.Minute)
+	if ok {
+		t.Fatal("next() sent a third message within the window")
+	}
+	if wait <= 0 || wait > time.Minute {
+		t.Errorf("next() wait = %s, want up to a minute", wait)
+	}
+	if q.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", q.Len())
+	}
+}
@@ -7,35 +7,37 @@ import (
 	"xabbo.b7c.io/goearth/shockwave/out"
 )
 
-// Dice struct represents a dice with its ID, value
+// Dice struct represents a dice with its ID, value and tile
 type Dice struct {
 	ID        int
 	Value     int
 	IsClosed  bool
 	IsRolling bool
+
+	// Tile position, known once the dice was seen in the room objects
+	X      int
+	Y      int
+	Placed bool
 }
 
-// Roll the dice
+// Roll the dice. The table tracks the rolling state.
 func (d *Dice) Roll() error {
 	if d.ID == 0 {
 		return errors.New("no dice id")
 	}
 
 	ext.Send(out.THROW_DICE, []byte(fmt.Sprintf("%d", d.ID)))
-	d.IsRolling = true
-	d.IsClosed = false
 	return nil
 }
 
-// Close the dice
+// Close the dice. Its value becomes 0 once the server confirms.
 func (d *Dice) Close() error {
 	if d.ID == 0 {
 		return errors.New("no dice id")
 	}
 
-	// Send the throw dice packet
+	// Send the dice off packet
 	ext.Send(out.DICE_OFF, []byte(fmt.Sprintf("%d", d.ID)))
-	d.IsClosed = true
 	return nil
 }
 
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// DICE_VALUE carries "<dice id> <raw value>". The raw value encodes the face
+// as id*multiplier + face, where face 0 is a closed dice. While a dice is
+// rolling the server sends the id alone, some hotels send -1 instead.
+const (
+	defaultDiceMultiplier = 38
+	rollingRawValue       = -1
+	faceClosed            = 0
+	faceMax               = 6
+)
+
+// Last DICE_VALUE that didn't decode, kept for :calibrate. Guarded by mutex.
+var lastUndecodable struct {
+	id  int
+	raw int
+}
+
+// decodeDiceValue returns the face of a raw DICE_VALUE. rolling is true for
+// the rolling sentinel, which carries no face.
+func decodeDiceValue(id int, raw int, multiplier int) (face int, rolling bool, err error) {
+	if raw == rollingRawValue {
+		return 0, true, nil
+	}
+	face = raw - id*multiplier
+	if face < faceClosed || face > faceMax {
+		return 0, false, fmt.Errorf("raw value %d of dice %d doesn't decode with multiplier %d (face %d)", raw, id, multiplier, face)
+	}
+	return face, false, nil
+}
+
+// reportUndecodable logs a DICE_VALUE that couldn't be decoded and keeps it
+// so the dealer can calibrate against it
+func (a *App) reportUndecodable(id int, raw int, err error) {
+	mutex.Lock()
+	lastUndecodable.id = id
+	lastUndecodable.raw = raw
+	mutex.Unlock()
+
+	hint := ""
+	if id > faceMax {
+		// With ids above 6 the face is the remainder, which gives the multiplier away
+		hint = fmt.Sprintf(" Multiplier %d would fit, use :calibrate <face> with the face shown.", raw/id)
+	}
+	a.AddLogMsg("Ignored dice result: " + err.Error() + "." + hint)
+}
+
+// calibrate is the :calibrate <face> command. It learns the multiplier from
+// the last dice result that didn't decode, using the face the dealer saw.
+func (a *App) calibrate(arg string) {
+	face, err := strconv.Atoi(arg)
+	if err != nil || face < faceClosed || face > faceMax {
+		a.AddLogMsg("Usage: :calibrate <face 0-6>, after a dice result was ignored")
+		return
+	}
+
+	mutex.Lock()
+	id, raw := lastUndecodable.id, lastUndecodable.raw
+	mutex.Unlock()
+	if id == 0 {
+		a.AddLogMsg("Calibrate failed: no ignored dice result to learn from. Throw a booth dice first.")
+		return
+	}
+	if (raw-face)%id != 0 || (raw-face)/id <= 0 {
+		a.AddLogMsg(fmt.Sprintf("Calibrate failed: raw value %d of dice %d can't show face %d", raw, id, face))
+		return
+	}
+	multiplier := (raw - face) / id
+
+	s := currentSettings()
+	s.DiceMultiplier = multiplier
+	a.SaveSettings(&s)
+	if currentSettings().DiceMultiplier != multiplier {
+		return
+	}
+
+	mutex.Lock()
+	lastUndecodable.id = 0
+	mutex.Unlock()
+	a.AddLogMsg(fmt.Sprintf("Dice values calibrated: multiplier %d", multiplier))
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "settingsUpdate")
+	}
+}
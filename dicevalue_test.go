@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestDecodeDiceValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int
+		raw        int
+		multiplier int
+		face       int
+		rolling    bool
+		wantErr    bool
+	}{
+		{name: "rolling sentinel", id: 5, raw: rollingRawValue, multiplier: defaultDiceMultiplier, rolling: true},
+		{name: "face", id: 5, raw: 5*38 + 4, multiplier: defaultDiceMultiplier, face: 4},
+		{name: "closed", id: 5, raw: 5 * 38, multiplier: defaultDiceMultiplier, face: faceClosed},
+		{name: "highest face", id: 5, raw: 5*38 + 6, multiplier: defaultDiceMultiplier, face: 6},
+		// A hotel with another multiplier sends faces the default can't read
+		{name: "off multiplier", id: 5, raw: 5*40 + 3, multiplier: defaultDiceMultiplier, wantErr: true},
+		{name: "below the dice's range", id: 5, raw: 5*38 - 1, multiplier: defaultDiceMultiplier, wantErr: true},
+		{name: "calibrated multiplier", id: 5, raw: 5*40 + 3, multiplier: 40, face: 3},
+		{name: "calibrated, closed", id: 123456, raw: 123456 * 40, multiplier: 40, face: faceClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			face, rolling, err := decodeDiceValue(tt.id, tt.raw, tt.multiplier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeDiceValue(%d, %d, %d) error = %v, want error %t", tt.id, tt.raw, tt.multiplier, err, tt.wantErr)
+			}
+			if face != tt.face || rolling != tt.rolling {
+				t.Errorf("decodeDiceValue(%d, %d, %d) = %d, %t, want %d, %t",
+					tt.id, tt.raw, tt.multiplier, face, rolling, tt.face, tt.rolling)
+			}
+		})
+	}
+}
@@ -4,12 +4,44 @@ import {main} from '../models';
 
 export function AddLogMsg(arg1:string):Promise<void>;
 
+export function ConfirmBooth(arg1:Array<number>):Promise<boolean>;
+
+export function DeleteSavedBooth(arg1:number):Promise<void>;
+
+export function ExportLanguageTemplate():Promise<void>;
+
+export function GetBoothProposals():Promise<Array<main.BoothProposal>>;
+
 export function GetCurrentVersion():Promise<string>;
 
+export function GetLedger():Promise<main.Ledger>;
+
+export function GetSavedBooths():Promise<Array<main.SavedBooth>>;
+
 export function LoadConfig():Promise<main.PokerDisplayConfig>;
 
+export function LoadItemValues():Promise<Array<main.ItemValue>>;
+
+export function LoadLanguages():Promise<Array<main.LanguageInfo>>;
+
+export function LoadSettings():Promise<main.BotSettings>;
+
+export function LoadTemplates():Promise<Array<main.MessageTemplate>>;
+
+export function LoadTimingProfiles():Promise<Array<main.TimingProfile>>;
+
+export function PreviewTemplate(arg1:string,arg2:string):Promise<main.TemplatePreview>;
+
 export function SaveConfig(arg1:main.PokerDisplayConfig):Promise<void>;
 
+export function SaveItemValues(arg1:Array<main.ItemValue>):Promise<void>;
+
+export function SaveSettings(arg1:main.BotSettings):Promise<void>;
+
+export function SaveTemplates(arg1:Array<main.MessageTemplate>):Promise<void>;
+
+export function SaveTimingProfiles(arg1:Array<main.TimingProfile>):Promise<void>;
+
 export function ShowCommands():Promise<void>;
 
 export function ShowWindow():Promise<void>;
@@ -6,18 +6,82 @@ export function AddLogMsg(arg1) {
   return window['go']['main']['App']['AddLogMsg'](arg1);
 }
 
+export function ConfirmBooth(arg1) {
+  return window['go']['main']['App']['ConfirmBooth'](arg1);
+}
+
+export function DeleteSavedBooth(arg1) {
+  return window['go']['main']['App']['DeleteSavedBooth'](arg1);
+}
+
+export function ExportLanguageTemplate() {
+  return window['go']['main']['App']['ExportLanguageTemplate']();
+}
+
+export function GetBoothProposals() {
+  return window['go']['main']['App']['GetBoothProposals']();
+}
+
 export function GetCurrentVersion() {
   return window['go']['main']['App']['GetCurrentVersion']();
 }
 
+export function GetLedger() {
+  return window['go']['main']['App']['GetLedger']();
+}
+
+export function GetSavedBooths() {
+  return window['go']['main']['App']['GetSavedBooths']();
+}
+
 export function LoadConfig() {
   return window['go']['main']['App']['LoadConfig']();
 }
 
+export function LoadItemValues() {
+  return window['go']['main']['App']['LoadItemValues']();
+}
+
+export function LoadLanguages() {
+  return window['go']['main']['App']['LoadLanguages']();
+}
+
+export function LoadSettings() {
+  return window['go']['main']['App']['LoadSettings']();
+}
+
+export function LoadTemplates() {
+  return window['go']['main']['App']['LoadTemplates']();
+}
+
+export function LoadTimingProfiles() {
+  return window['go']['main']['App']['LoadTimingProfiles']();
+}
+
+export function PreviewTemplate(arg1, arg2) {
+  return window['go']['main']['App']['PreviewTemplate'](arg1, arg2);
+}
+
 export function SaveConfig(arg1) {
   return window['go']['main']['App']['SaveConfig'](arg1);
 }
 
+export function SaveItemValues(arg1) {
+  return window['go']['main']['App']['SaveItemValues'](arg1);
+}
+
+export function SaveSettings(arg1) {
+  return window['go']['main']['App']['SaveSettings'](arg1);
+}
+
+export function SaveTemplates(arg1) {
+  return window['go']['main']['App']['SaveTemplates'](arg1);
+}
+
+export function SaveTimingProfiles(arg1) {
+  return window['go']['main']['App']['SaveTimingProfiles'](arg1);
+}
+
 export function ShowCommands() {
   return window['go']['main']['App']['ShowCommands']();
 }
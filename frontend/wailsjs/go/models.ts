@@ -1,5 +1,233 @@
 export namespace main {
 	
+	export class BoothDice {
+	    id: number;
+	    x: number;
+	    y: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BoothDice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	    }
+	}
+	
+	export class BoothProposal {
+	    x: number;
+	    y: number;
+	    dice: BoothDice[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BoothProposal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.dice = this.convertValues(source["dice"], BoothDice);
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class BotSettings {
+	    max_risks: number;
+	    max_balance: number;
+	    choice_timeout: number;
+	    choice_timeout_action: string;
+	    dice_retries: number;
+	    void_policy: string;
+	    interference_policy: string;
+	    dice_multiplier: number;
+	    timing_profile: string;
+	    chat_limit: number;
+	    chat_window: number;
+	    pause_games_while_muted: boolean;
+	    booth_layout: string;
+	    channels: Record<string, string>;
+	    language: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BotSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max_risks = source["max_risks"];
+	        this.max_balance = source["max_balance"];
+	        this.choice_timeout = source["choice_timeout"];
+	        this.choice_timeout_action = source["choice_timeout_action"];
+	        this.dice_retries = source["dice_retries"];
+	        this.void_policy = source["void_policy"];
+	        this.interference_policy = source["interference_policy"];
+	        this.dice_multiplier = source["dice_multiplier"];
+	        this.timing_profile = source["timing_profile"];
+	        this.chat_limit = source["chat_limit"];
+	        this.chat_window = source["chat_window"];
+	        this.pause_games_while_muted = source["pause_games_while_muted"];
+	        this.booth_layout = source["booth_layout"];
+	        this.channels = source["channels"];
+	        this.language = source["language"];
+	    }
+	}
+	
+	export class Delay {
+	    min: number;
+	    max: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Delay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.min = source["min"];
+	        this.max = source["max"];
+	    }
+	}
+	
+	export class ItemValue {
+	    class: string;
+	    value: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ItemValue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.class = source["class"];
+	        this.value = source["value"];
+	    }
+	}
+	
+	export class LanguageInfo {
+	    code: string;
+	    name: string;
+	    missing: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LanguageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.name = source["name"];
+	        this.missing = source["missing"];
+	    }
+	}
+	
+	export class Ledger {
+	    liabilities: Liability[];
+	    classes: LedgerClass[];
+	    shortfall: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Ledger(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.liabilities = this.convertValues(source["liabilities"], Liability);
+	        this.classes = this.convertValues(source["classes"], LedgerClass);
+	        this.shortfall = source["shortfall"];
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class LedgerClass {
+	    class: string;
+	    in_hand: number;
+	    reserved: number;
+	    available: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LedgerClass(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.class = source["class"];
+	        this.in_hand = source["in_hand"];
+	        this.reserved = source["reserved"];
+	        this.available = source["available"];
+	    }
+	}
+	
+	export class Liability {
+	    source: string;
+	    credits: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Liability(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.credits = source["credits"];
+	    }
+	}
+	
+	export class MessageTemplate {
+	    key: string;
+	    description: string;
+	    text: string;
+	    placeholders: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MessageTemplate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.description = source["description"];
+	        this.text = source["text"];
+	        this.placeholders = source["placeholders"];
+	    }
+	}
+	
 	export class PokerDisplayConfig {
 	    five_of_a_kind: string;
 	    four_of_a_kind: string;
@@ -28,6 +256,101 @@ export namespace main {
 	        this.nothing = source["nothing"];
 	    }
 	}
+	
+	export class SavedBooth {
+	    room_id: number;
+	    dice: BoothDice[];
+	    saved: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SavedBooth(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.room_id = source["room_id"];
+	        this.dice = this.convertValues(source["dice"], BoothDice);
+	        this.saved = source["saved"];
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TemplatePreview {
+	    text: string;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TemplatePreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.error = source["error"];
+	    }
+	}
+	
+	export class TimingProfile {
+	    name: string;
+	    throw_spacing: Delay;
+	    close_spacing: Delay;
+	    result_settle: Delay;
+	    hit_pause: Delay;
+	    pre_announce: Delay;
+	    chat_typing: Delay;
+	    phase_pause: Delay;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimingProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.throw_spacing = this.convertValues(source["throw_spacing"], Delay);
+	        this.close_spacing = this.convertValues(source["close_spacing"], Delay);
+	        this.result_settle = this.convertValues(source["result_settle"], Delay);
+	        this.hit_pause = this.convertValues(source["hit_pause"], Delay);
+	        this.pre_announce = this.convertValues(source["pre_announce"], Delay);
+	        this.chat_typing = this.convertValues(source["chat_typing"], Delay);
+	        this.phase_pause = this.convertValues(source["phase_pause"], Delay);
+	    }
+
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
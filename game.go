@@ -0,0 +1,305 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Game is a dice game the dealer can run on the table. Registering a Game
+// is all it takes to make it playable from chat, by a session player and
+// to list it in :commands.
+type Game interface {
+	// Name is used in logs and the session history ("Poker", "21")
+	Name() string
+	// Commands are the chat commands that start the game, without ':'
+	Commands() []string
+	// Help is the :commands description
+	Help() string
+	// DiceNeeded is the number of booth dice the game uses
+	DiceNeeded() int
+	// RollPlan tells the table which dice to throw for one hand
+	RollPlan() RollPlan
+	// Evaluate scores the values thrown for one hand, in throw order
+	Evaluate(a *App, values []int) Hand
+	// Compare settles a player hand against a dealer hand. The string is
+	// the message template key announcing it.
+	Compare(player Hand, dealer Hand) (roundOutcome, string)
+	// Announce is the chat text for a hand
+	Announce(hand Hand) string
+	// Payout is the bet multiplier for a winning player hand
+	Payout(player Hand) float64
+	// MaxPayout is the highest multiplier Payout can return
+	MaxPayout() float64
+}
+
+// RollPlan describes how one hand is thrown on the booth. Dice are given as
+// booth positions ("left", "center", ...), see layout.go.
+type RollPlan struct {
+	// Turn the booth off before throwing
+	CloseFirst bool
+	// Dice thrown for the opening of the hand
+	Opening []string
+	// Dice thrown one at a time while the hand scores below HitBelow. Once
+	// all are used the last one is thrown again.
+	Hits     []string
+	HitBelow int
+	// Play against the dealer even without a session
+	Versus bool
+}
+
+// Hand is the evaluated result of one hand
+type Hand struct {
+	Score       int
+	Description string
+	// Short name used when comparing hands ("Full House", "17")
+	Rank        string
+	Tiebreakers []int
+	DiceValues  []int
+}
+
+func (h Hand) DiceString() string {
+	parts := make([]string, 0, len(h.DiceValues))
+	for _, value := range h.DiceValues {
+		parts = append(parts, strconv.Itoa(value))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, ","))
+}
+
+// Registered games in :commands order
+var games []Game
+
+// Highest payout multiplier of the registered games, what the ledger
+// reserves for every bet since the player picks the game after betting
+var maxPayout float64
+
+func registerGame(game Game) {
+	games = append(games, game)
+	if game.MaxPayout() > maxPayout {
+		maxPayout = game.MaxPayout()
+	}
+}
+
+// winPayout is the most a bet of credits can pay out with any game, paid
+// the way settleRound pays it
+func winPayout(credits int) int {
+	return int(float64(credits) * maxPayout)
+}
+
+// gameForCommand returns the game started by a chat command, if any
+func gameForCommand(command string) Game {
+	command = strings.ToLower(strings.TrimSpace(command))
+	for _, game := range games {
+		for _, c := range game.Commands() {
+			if c == command {
+				return game
+			}
+		}
+	}
+	return nil
+}
+
+// gameChoiceList lists the main command of each game (":pkr, :tri, ...")
+func gameChoiceList() string {
+	commands := make([]string, 0, len(games))
+	for _, game := range games {
+		commands = append(commands, ":"+game.Commands()[0])
+	}
+	return strings.Join(commands, ", ")
+}
+
+// gameCommandsHelp is the games part of the :commands screen
+func gameCommandsHelp(lang string) string {
+	var b strings.Builder
+	for _, game := range games {
+		for _, c := range game.Commands() {
+			b.WriteString(":" + c + " ")
+		}
+		help, ok := translate(lang, gameHelpKey(game))
+		if !ok {
+			help = game.Help()
+		}
+		b.WriteString("\n" + help + "\n")
+		b.WriteString("------------------------------------\n")
+	}
+	return b.String()
+}
+
+// startGame starts a game on the table. It returns why it couldn't: the
+// dealer is muted, the session holds a won balance, the booth doesn't have
+// enough dice or the table is busy.
+func (a *App) startGame(game Game) error {
+	if left := mute.Remaining(); left > 0 && currentSettings().PauseGamesWhileMuted {
+		return fmt.Errorf("muted for %ds more", int(left.Seconds())+1)
+	}
+	if sessionHoldsBalance() {
+		return errors.New("the session balance is waiting for :risk or :cashout")
+	}
+	if have := table.Len(); have < game.DiceNeeded() {
+		return fmt.Errorf("needs %d dice, booth has %d", game.DiceNeeded(), have)
+	}
+	if err := table.Begin(game.Name()); err != nil {
+		return err
+	}
+	a.AddLogMsg(game.Name() + " Roll:\n")
+
+	go func() {
+		replay := a.playGame(game)
+		table.Finish()
+		if !replay {
+			return
+		}
+		if err := a.startGame(game); err != nil {
+			a.AddLogMsg(game.Name() + " replay couldn't start: " + err.Error())
+			a.awaitChoiceAfterVoid()
+		}
+	}()
+	return nil
+}
+
+// playGame plays a round against the dealer when a bet is riding on it,
+// otherwise it rolls and announces a single hand. It returns true when a
+// voided round should be played again.
+func (a *App) playGame(game Game) bool {
+	if beginSessionRound(game.Name()) || game.RollPlan().Versus {
+		return a.playRound(game)
+	}
+
+	hand, err := a.rollHand(game)
+	if err == nil {
+		// Hold the result while the game is paused
+		err = table.WaitIfPaused()
+	}
+	if err != nil {
+		return a.voidRound(game.Name(), err)
+	}
+	if err := table.Settle(); err != nil {
+		log.Println(err)
+	}
+	text := a.logAndSayResult(game.Name(), msgHandSolo, msgVars{
+		"game": game.Name(),
+		"hand": game.Announce(hand),
+		"dice": hand.DiceString(),
+	})
+	table.SetLastResult(text)
+	return false
+}
+
+// playRound plays the player's hand, then the dealer's, and settles the
+// session on the comparison. It returns true when the round was voided and
+// should be played again.
+func (a *App) playRound(game Game) bool {
+	playerName := sessionPlayer()
+	player, err := a.rollHand(game)
+	if err != nil {
+		return a.voidRound(game.Name(), err)
+	}
+	a.logAndSayResult(game.Name(), msgHandPlayer, msgVars{
+		"game":        game.Name(),
+		"player":      playerName,
+		"player_hand": game.Announce(player),
+		"dice":        player.DiceString(),
+	})
+
+	activeTiming().PhasePause.Sleep()
+
+	dealer, err := a.rollHand(game)
+	if err == nil {
+		// Hold the result while the game is paused
+		err = table.WaitIfPaused()
+	}
+	if err != nil {
+		return a.voidRound(game.Name(), err)
+	}
+	if err := table.Settle(); err != nil {
+		log.Println(err)
+	}
+	a.logAndSayResult(game.Name(), msgHandDealer, msgVars{
+		"game":        game.Name(),
+		"player":      playerName,
+		"dealer_hand": game.Announce(dealer),
+		"dice":        dealer.DiceString(),
+	})
+
+	outcome, resultKey := game.Compare(player, dealer)
+	resultMessage := a.logAndSayResult(game.Name(), resultKey, msgVars{
+		"game":        game.Name(),
+		"player":      playerName,
+		"player_hand": player.Rank,
+		"dealer_hand": dealer.Rank,
+	})
+	table.SetLastResult(resultMessage)
+	a.settleRound(game.Name(), outcome, game.Payout(player), resultMessage)
+	return false
+}
+
+// resultKey is the message announcing a plain win, loss or tie
+func resultKey(outcome roundOutcome) string {
+	switch outcome {
+	case outcomeWin:
+		return msgPlayerWins
+	case outcomeLose:
+		return msgDealerWins
+	default:
+		return msgTie
+	}
+}
+
+// How long a throw waits for dice results before throwing the missing dice
+// again
+const diceResultTimeout = 3 * time.Second
+
+// rollHand throws one hand following the game's roll plan
+func (a *App) rollHand(game Game) (Hand, error) {
+	plan := game.RollPlan()
+	opening, err := table.Resolve(plan.Opening)
+	if err != nil {
+		return Hand{}, fmt.Errorf("can't be rolled on this booth: %w", err)
+	}
+	hits, err := table.Resolve(plan.Hits)
+	if err != nil {
+		return Hand{}, fmt.Errorf("can't be rolled on this booth: %w", err)
+	}
+	return a.throwHand(game, plan, opening, hits)
+}
+
+// throwHand throws the resolved opening and hit dice of a roll plan
+func (a *App) throwHand(game Game, plan RollPlan, opening []int, hits []int) (Hand, error) {
+	retries := currentSettings().DiceRetries
+	if plan.CloseFirst {
+		table.CloseAll()
+	}
+
+	if err := table.RollDice(opening, diceResultTimeout, retries); err != nil {
+		return Hand{}, err
+	}
+	values := table.Values(opening)
+	hand := game.Evaluate(a, values)
+
+	for len(hits) > 0 && hand.Score < plan.HitBelow {
+		log.Printf("Score is less than %d. Hitting another dice.", plan.HitBelow)
+
+		// Throw the next closed dice, or the last one again once all are used
+		next := -1
+		for i, value := range table.Values(hits) {
+			if value == 0 {
+				next = hits[i]
+				break
+			}
+		}
+		if next == -1 {
+			next = hits[len(hits)-1]
+			activeTiming().HitPause.Sleep()
+		}
+
+		if err := table.RollDice([]int{next}, diceResultTimeout, retries); err != nil {
+			return Hand{}, err
+		}
+		values = append(values, table.Values([]int{next})...)
+		hand = game.Evaluate(a, values)
+	}
+	return hand, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	g "xabbo.b7c.io/goearth"
+)
+
+// Handle incoming room chat, the session player picks their game, risks or
+// cashes out here
+func (a *App) handleRoomChat(e *g.Intercept) {
+	r := newWireReader(e.Packet.Data)
+	index, err := r.readInt()
+	if err != nil {
+		return
+	}
+	msg, err := r.readString()
+	if err != nil {
+		return
+	}
+
+	name, ok := lookupRoomName(index)
+	if !ok {
+		return
+	}
+
+	mutex.Lock()
+	isPlayer := session.Active && strings.EqualFold(name, session.PlayerName)
+	waiting := isPlayer && session.AwaitingGameChoice
+	mutex.Unlock()
+
+	// The player can pick their language at any point of the session
+	if code, ok := strings.CutPrefix(strings.TrimSpace(msg), ":lang "); ok && isPlayer {
+		a.setPlayerLanguage(name, code)
+		return
+	}
+	// What the win and risk messages tell the player to use. risk and
+	// cashOut refuse when there's no balance.
+	if isPlayer {
+		switch strings.ToLower(strings.TrimSpace(msg)) {
+		case ":risk":
+			a.AddLogMsg(name + " asked to risk")
+			go a.risk()
+			return
+		case ":cashout":
+			a.AddLogMsg(name + " asked to cash out")
+			go a.cashOut()
+			return
+		}
+	}
+	if !waiting {
+		return
+	}
+
+	game := gameForCommand(strings.TrimPrefix(strings.TrimSpace(msg), ":"))
+	if game == nil {
+		return
+	}
+
+	a.AddLogMsg(fmt.Sprintf("%s chose %s", name, game.Name()))
+	if currentSettings().PauseGamesWhileMuted && mute.Muted() {
+		mutex.Lock()
+		session.HeldGame = game.Commands()[0]
+		mutex.Unlock()
+		a.AddLogMsg(game.Name() + " held: muted, it starts when the mute ends")
+		return
+	}
+	if err := a.startGame(game); err != nil {
+		a.AddLogMsg(game.Name() + " not started: " + err.Error())
+	}
+}
+
+// startHeldGame starts the game the player chose while the dealer was muted
+func (a *App) startHeldGame() {
+	mutex.Lock()
+	command := session.HeldGame
+	session.HeldGame = ""
+	waiting := session.Active && session.AwaitingGameChoice
+	mutex.Unlock()
+
+	game := gameForCommand(command)
+	if game == nil || !waiting {
+		return
+	}
+	if err := a.startGame(game); err != nil {
+		a.AddLogMsg(game.Name() + " not started: " + err.Error())
+	}
+}
+
+// runChoiceTimeouts reminds a session player who hasn't chosen a game and
+// refunds or ends the session once the choice timeout runs out. The timeout
+// stands still while games are paused by a mute.
+func (a *App) runChoiceTimeouts() {
+	last := time.Now()
+	for {
+		time.Sleep(1 * time.Second)
+		now := time.Now()
+		tick := now.Sub(last)
+		last = now
+
+		limits := currentSettings()
+		if limits.PauseGamesWhileMuted && mute.Muted() {
+			mutex.Lock()
+			if session.AwaitingGameChoice {
+				session.ChoiceSince = session.ChoiceSince.Add(tick)
+			}
+			mutex.Unlock()
+			continue
+		}
+
+		timeout := time.Duration(limits.ChoiceTimeout) * time.Second
+		if timeout <= 0 {
+			continue
+		}
+
+		mutex.Lock()
+		if !session.Active || !session.AwaitingGameChoice || session.ChoiceExpired || payout.Active {
+			mutex.Unlock()
+			continue
+		}
+		waited := time.Since(session.ChoiceSince)
+		player := session.PlayerName
+
+		remind := !session.ChoiceReminded && waited >= timeout/2
+		if remind {
+			session.ChoiceReminded = true
+		}
+		expired := waited >= timeout
+		if expired {
+			session.ChoiceExpired = true
+			recordSession(session, "choice_timeout", "")
+		}
+		mutex.Unlock()
+
+		if remind && !expired {
+			left := int((timeout - waited).Seconds())
+			a.logAndSay("Session reminder", msgChoiceReminder, msgVars{
+				"player":  player,
+				"games":   gameChoiceList(),
+				"seconds": strconv.Itoa(left),
+			})
+		}
+		if expired {
+			a.expireGameChoice(player)
+		}
+	}
+}
+
+func (a *App) expireGameChoice(player string) {
+	if currentSettings().ChoiceTimeoutAction == "end" {
+		a.AddLogMsg(fmt.Sprintf("Session ended: %s never chose a game.", player))
+		a.logAndSay("Session ended", msgChoiceExpired, msgVars{"player": player})
+		endSession("no game chosen")
+		return
+	}
+
+	a.AddLogMsg(fmt.Sprintf("%s never chose a game, refunding the bet.", player))
+	a.refundBet()
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GameDefinition is a game described in a game_*.json file in the URTBOT
+// config directory, for variants that don't need Go code. Example:
+//
+//	{
+//	  "name": "17",
+//	  "commands": ["17"],
+//	  "help": "Blackjack to 17",
+//	  "opening": ["left", "left-center"],
+//	  "hits": ["center", "right-center", "right"],
+//	  "stand_at": 12,
+//	  "close_first": true,
+//	  "scoring": "sum",
+//	  "bust_over": 17,
+//	  "payout": {"win": 2, "scores": {"17": 3}}
+//	}
+type GameDefinition struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands"`
+	Help     string   `json:"help"`
+
+	// Booth positions thrown for the opening, then one at a time as hits
+	// while the score is below stand_at
+	Opening    []string `json:"opening"`
+	Hits       []string `json:"hits"`
+	StandAt    int      `json:"stand_at"`
+	CloseFirst bool     `json:"close_first"`
+	// Play against the dealer even without a session
+	Versus bool `json:"versus"`
+
+	// "sum", "poker" or "highest"
+	Scoring string `json:"scoring"`
+	// Sum scoring only: a score over bust_over loses, 0 means no bust
+	BustOver int `json:"bust_over"`
+
+	Payout PayoutRules `json:"payout"`
+}
+
+// PayoutRules are the multipliers applied to the bet when the player wins
+type PayoutRules struct {
+	// Multiplier for any win, 2 when left out
+	Win float64 `json:"win"`
+	// Multipliers for winning with a specific score
+	Scores map[int]float64 `json:"scores"`
+}
+
+const (
+	scoringSum     = "sum"
+	scoringPoker   = "poker"
+	scoringHighest = "highest"
+)
+
+// Commands handled by the dealer command switch before games are looked up.
+// The switch matches most of them by suffix so game commands can't end with
+// them either.
+var reservedCommands = []string{
+	"session", "endsession", "cashout", "risk", "reset", "close", "verify", "commands", "chaton", "chatoff", "void", "resume", "calibrate", "lang",
+}
+
+// Commands the switch matches by prefix, so game commands can't start with
+// them
+var reservedPrefixes = []string{"session", "lang", "calibrate", "@"}
+
+// reservedClash returns the dealer command a game command would be taken
+// for, matched the way the command switch matches it
+func reservedClash(command string) (string, bool) {
+	for _, reserved := range reservedPrefixes {
+		if strings.HasPrefix(command, reserved) {
+			return reserved, true
+		}
+	}
+	for _, reserved := range reservedCommands {
+		if strings.HasSuffix(command, reserved) {
+			return reserved, true
+		}
+	}
+	return "", false
+}
+
+// loadGameDefinitions registers every valid game_*.json file in the config
+// directory. Invalid files are logged and skipped.
+func (a *App) loadGameDefinitions() {
+	files, err := filepath.Glob(configFilePath("game_*.json"))
+	if err != nil {
+		a.AddLogMsg("Error listing game definitions: " + err.Error())
+		return
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		def, err := readGameDefinition(file)
+		if err == nil {
+			err = def.validate()
+		}
+		if err != nil {
+			a.AddLogMsg(fmt.Sprintf("Skipping game definition %s: %v", filepath.Base(file), err))
+			continue
+		}
+		registerGame(definedGame{def})
+		a.AddLogMsg(fmt.Sprintf("Loaded game %s (:%s)", def.Name, strings.Join(def.Commands, ", :")))
+	}
+}
+
+func readGameDefinition(path string) (GameDefinition, error) {
+	var def GameDefinition
+	file, err := os.Open(path)
+	if err != nil {
+		return def, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&def); err != nil {
+		return def, err
+	}
+	if def.Payout.Win == 0 {
+		def.Payout.Win = 2
+	}
+	for i, c := range def.Commands {
+		def.Commands[i] = strings.ToLower(strings.TrimSpace(c))
+	}
+	return def, nil
+}
+
+func (d GameDefinition) validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(d.Commands) == 0 {
+		return fmt.Errorf("at least one command is required")
+	}
+	for _, c := range d.Commands {
+		if c == "" || strings.ContainsAny(c, ": @") {
+			return fmt.Errorf("invalid command %q", c)
+		}
+		if reserved, clash := reservedClash(c); clash {
+			return fmt.Errorf("command %q clashes with :%s", c, reserved)
+		}
+		if gameForCommand(c) != nil {
+			return fmt.Errorf("command %q is already used by %s", c, gameForCommand(c).Name())
+		}
+	}
+
+	if len(d.Opening) == 0 {
+		return fmt.Errorf("opening needs at least one dice")
+	}
+	positions := d.positions()
+	needed, err := positionsNeeded(positions)
+	if err != nil {
+		return err
+	}
+	if _, err := resolvePositions(positions, needed); err != nil {
+		return err
+	}
+	if len(d.Hits) > 0 && d.StandAt <= 0 {
+		return fmt.Errorf("stand_at is required when hits are set")
+	}
+
+	switch d.Scoring {
+	case scoringSum, scoringHighest:
+	case scoringPoker:
+		if len(d.Opening) != 5 || len(d.Hits) > 0 {
+			return fmt.Errorf("poker scoring needs exactly 5 opening dice and no hits")
+		}
+	default:
+		return fmt.Errorf("unknown scoring %q (sum, poker or highest)", d.Scoring)
+	}
+	if d.BustOver < 0 {
+		return fmt.Errorf("bust_over can't be negative")
+	}
+	if d.BustOver > 0 && d.Scoring != scoringSum {
+		return fmt.Errorf("bust_over only applies to sum scoring")
+	}
+
+	if d.Payout.Win < 1 {
+		return fmt.Errorf("payout win must be at least 1")
+	}
+	for score, multiplier := range d.Payout.Scores {
+		if multiplier < 1 {
+			return fmt.Errorf("payout for score %d must be at least 1", score)
+		}
+	}
+	return nil
+}
+
+// positions returns the opening and hit positions together
+func (d GameDefinition) positions() []string {
+	return append(append([]string(nil), d.Opening...), d.Hits...)
+}
+
+// definedGame plays a GameDefinition
+type definedGame struct {
+	def GameDefinition
+}
+
+func (d definedGame) Name() string       { return d.def.Name }
+func (d definedGame) Commands() []string { return d.def.Commands }
+
+func (d definedGame) Help() string {
+	if d.def.Help == "" {
+		return d.def.Name + " (custom game). "
+	}
+	return d.def.Help
+}
+
+// DiceNeeded is the smallest booth the positions fit on
+func (d definedGame) DiceNeeded() int {
+	needed, _ := positionsNeeded(d.def.positions())
+	return needed
+}
+
+func (d definedGame) RollPlan() RollPlan {
+	return RollPlan{
+		CloseFirst: d.def.CloseFirst,
+		Opening:    d.def.Opening,
+		Hits:       d.def.Hits,
+		HitBelow:   d.def.StandAt,
+		Versus:     d.def.Versus,
+	}
+}
+
+func (d definedGame) Evaluate(a *App, values []int) Hand {
+	switch d.def.Scoring {
+	case scoringPoker:
+		return a.pokerHand(values)
+	case scoringHighest:
+		return highestHandResult(values)
+	default:
+		return sumHandResult(values)
+	}
+}
+
+func (d definedGame) Compare(player Hand, dealer Hand) (roundOutcome, string) {
+	switch d.def.Scoring {
+	case scoringPoker:
+		outcome := pokerOutcome(player, dealer)
+		return outcome, resultKey(outcome)
+	case scoringHighest:
+		return compareHighest(player, dealer)
+	default:
+		limit := d.def.BustOver
+		if limit == 0 {
+			// Nothing can bust, every sum is under the limit
+			limit = 6 * maxBoothDice
+		}
+		return compareSums(player.Score, dealer.Score, limit)
+	}
+}
+
+func (d definedGame) Announce(hand Hand) string {
+	return hand.Description
+}
+
+func (d definedGame) Payout(player Hand) float64 {
+	if multiplier, ok := d.def.Payout.Scores[player.Score]; ok {
+		return multiplier
+	}
+	return d.def.Payout.Win
+}
+
+func (d definedGame) MaxPayout() float64 {
+	highest := d.def.Payout.Win
+	for _, multiplier := range d.def.Payout.Scores {
+		if multiplier > highest {
+			highest = multiplier
+		}
+	}
+	return highest
+}
+
+// highestHandResult scores a hand by its highest dice, the others break ties
+func highestHandResult(values []int) Hand {
+	sorted := append([]int(nil), values...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	score := 0
+	if len(sorted) > 0 {
+		score = sorted[0]
+	}
+	return Hand{
+		Score:       score,
+		Description: fmt.Sprintf("high %d", score),
+		Rank:        strconv.Itoa(score),
+		Tiebreakers: sorted,
+		DiceValues:  append([]int(nil), values...),
+	}
+}
+
+// compareHighest compares highest dice first, then the next ones down
+func compareHighest(player Hand, dealer Hand) (roundOutcome, string) {
+	for i := 0; i < len(player.Tiebreakers) && i < len(dealer.Tiebreakers); i++ {
+		p, d := player.Tiebreakers[i], dealer.Tiebreakers[i]
+		if p > d {
+			return outcomeWin, msgPlayerWins
+		}
+		if d > p {
+			return outcomeLose, msgDealerWins
+		}
+	}
+	return outcomePush, msgTie
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestReservedClash(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		clash   bool
+	}{
+		{command: "dice21", clash: false},
+		{command: "poker", clash: false},
+		{command: "calibrate2", want: "calibrate", clash: true},
+		{command: "session1", want: "session", clash: true},
+		{command: "language", want: "lang", clash: true},
+		{command: "@dice", want: "@", clash: true},
+		{command: "fastrisk", want: "risk", clash: true},
+		{command: "autochatoff", want: "chatoff", clash: true},
+	}
+	for _, tt := range tests {
+		got, clash := reservedClash(tt.command)
+		if got != tt.want || clash != tt.clash {
+			t.Errorf("reservedClash(%q) = %q, %t, want %q, %t", tt.command, got, clash, tt.want, tt.clash)
+		}
+	}
+}
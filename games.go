@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strconv"
+)
+
+func init() {
+	registerGame(pokerGame{})
+	registerGame(triGame{})
+	registerGame(sumGame{
+		name:     "21",
+		help:     "Auto rolls and if chat is enabled \nsays the sum in chat when > 15. ",
+		opening:  []string{PosLeft, PosLeftCenter, PosCenter},
+		hits:     []string{PosRightCenter, PosRight},
+		standAt:  15,
+		bustOver: 21,
+	})
+	registerGame(sumGame{
+		name:     "13",
+		help:     "Auto rolls and if chat is enabled \nsays the sum in chat when > 8. ",
+		opening:  []string{PosLeft, PosLeftCenter},
+		hits:     []string{PosCenter, PosRightCenter, PosRight},
+		standAt:  7,
+		bustOver: 13,
+	})
+}
+
+// pokerGame rolls all five dice, best poker hand wins
+type pokerGame struct{}
+
+func (pokerGame) Name() string       { return "Poker" }
+func (pokerGame) Commands() []string { return []string{"pkr", "roll"} }
+func (pokerGame) DiceNeeded() int    { return 5 }
+
+func (pokerGame) Help() string {
+	return "Rolls 5 dice and if chat is enabled \nsays the results in chat. "
+}
+
+func (pokerGame) RollPlan() RollPlan {
+	return RollPlan{
+		Opening: []string{PosLeft, PosLeftCenter, PosCenter, PosRightCenter, PosRight},
+		Versus:  true,
+	}
+}
+
+func (pokerGame) Evaluate(a *App, values []int) Hand {
+	return a.pokerHand(values)
+}
+
+func (pokerGame) Compare(player Hand, dealer Hand) (roundOutcome, string) {
+	outcome := pokerOutcome(player, dealer)
+	return outcome, resultKey(outcome)
+}
+
+func (pokerGame) Announce(hand Hand) string {
+	return hand.Description
+}
+
+func (pokerGame) Payout(player Hand) float64 { return 2 }
+func (pokerGame) MaxPayout() float64         { return 2 }
+
+// triGame rolls three dice in tri formation, highest sum wins
+type triGame struct{}
+
+func (triGame) Name() string       { return "Tri" }
+func (triGame) Commands() []string { return []string{"tri"} }
+func (triGame) DiceNeeded() int    { return 3 }
+
+func (triGame) Help() string {
+	return "Auto rolls 3 dice in Tri Formation \nif chat is enabled says the \nresults in chat. "
+}
+
+func (triGame) RollPlan() RollPlan {
+	return RollPlan{Opening: []string{PosLeft, PosCenter, PosRight}}
+}
+
+func (triGame) Evaluate(a *App, values []int) Hand {
+	return sumHandResult(values)
+}
+
+func (triGame) Compare(player Hand, dealer Hand) (roundOutcome, string) {
+	// Tri can't bust, 18 is the highest possible sum
+	return compareSums(player.Score, dealer.Score, 18)
+}
+
+func (triGame) Announce(hand Hand) string {
+	return hand.Description
+}
+
+func (triGame) Payout(player Hand) float64 { return 2 }
+func (triGame) MaxPayout() float64         { return 2 }
+
+// sumGame is a blackjack style game: roll the opening dice, hit until the sum
+// reaches standAt, closest to bustOver without going over wins.
+type sumGame struct {
+	name     string
+	help     string
+	opening  []string
+	hits     []string
+	standAt  int
+	bustOver int
+}
+
+func (s sumGame) Name() string       { return s.name }
+func (s sumGame) Commands() []string { return []string{s.name} }
+func (s sumGame) Help() string       { return s.help }
+func (s sumGame) DiceNeeded() int    { return len(s.opening) + len(s.hits) }
+
+func (s sumGame) RollPlan() RollPlan {
+	return RollPlan{
+		CloseFirst: true,
+		Opening:    s.opening,
+		Hits:       s.hits,
+		HitBelow:   s.standAt,
+	}
+}
+
+func (s sumGame) Evaluate(a *App, values []int) Hand {
+	return sumHandResult(values)
+}
+
+func (s sumGame) Compare(player Hand, dealer Hand) (roundOutcome, string) {
+	return compareSums(player.Score, dealer.Score, s.bustOver)
+}
+
+func (s sumGame) Announce(hand Hand) string {
+	return hand.Description
+}
+
+func (s sumGame) Payout(player Hand) float64 { return 2 }
+func (s sumGame) MaxPayout() float64         { return 2 }
+
+// sumHandResult scores a hand by the sum of its dice
+func sumHandResult(values []int) Hand {
+	sum := sumHandInt(values)
+	return Hand{
+		Score:       sum,
+		Description: strconv.Itoa(sum),
+		Rank:        strconv.Itoa(sum),
+		Tiebreakers: []int{sum},
+		DiceValues:  append([]int(nil), values...),
+	}
+}
+
+// compareSums compares two sum totals where going over limit busts
+func compareSums(player int, dealer int, limit int) (roundOutcome, string) {
+	switch {
+	case player > limit:
+		return outcomeLose, msgPlayerBusts
+	case dealer > limit:
+		return outcomeWin, msgDealerBusts
+	case player > dealer:
+		return outcomeWin, msgPlayerWins
+	case dealer > player:
+		return outcomeLose, msgDealerWins
+	default:
+		return outcomePush, msgTie
+	}
+}
@@ -3,252 +3,37 @@ package main
 import (
 	"fmt"
 	"log"
-	"math/rand"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
-
-	"xabbo.b7c.io/goearth/shockwave/out"
 )
 
-// Send message with a delay to simulate user typing/waiting
-func sendMessageWithDelay(message string) {
-	// sleep random between 250 and 500ms
-	time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-	ext.Send(out.SHOUT, message)
-	log.Printf("Sent message: %s", message)
-}
-
-// Wait for all dice results and evaluate the poker hand
-func (a *App) evaluatePokerHand() {
-	if !ChatIsDisabled {
-		hand := a.toPokerHandResult(diceList)
-		logRollResult := fmt.Sprintf("Poker Result: %s\n", hand.Description)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-
-		if !isMuted {
-			// If the user is not muted, send the message
-			sendMessageWithDelay(hand.Description)
-		} else {
-			// If the user is muted, queue the message to send later
-			log.Printf("User is muted. Queuing message: %s", hand.Description)
-			// ToDo:
-			// messageQueue = append(messageQueue, hand)
-		}
-	} else {
-		hand := a.toPokerHandResult(diceList)
-		logRollResult := fmt.Sprintf("Poker Result: %s\n", hand.Description)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-	}
-	isPokerRolling = false
+// logAndSay logs a message and queues its template for chat when chat is
+// on, on the channel set for its message type
+func (a *App) logAndSay(logMessage string, key string, vars msgVars) {
+	text := renderMessage(key, vars)
+	a.logAndQueue(logMessage, routeChat(messageType(key), text, vars["player"], 0))
 }
 
-func (a *App) evaluatePokerRound() {
-	// Player roll already done at this point
-	playerHand := a.toPokerHandResult(diceList)
-	playerMessage := fmt.Sprintf("Player has %s %s", playerHand.Description, playerHand.DiceString())
-	a.logAndMaybeShout("Poker Result: "+playerMessage, playerMessage)
-
-	time.Sleep(3 * time.Second)
-
-	// Dealer re-roll (same dice)
-	for _, dice := range diceList {
-		dice.IsRolling = false
-	}
-	resultsWaitGroup.Add(len(diceList))
-	for _, dice := range diceList {
-		dice.Roll()
-		time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	}
-
-	time.Sleep(1000 * time.Millisecond)
-	resultsWaitGroup.Wait()
-
-	dealerHand := a.toPokerHandResult(diceList)
-	dealerMessage := fmt.Sprintf("Dealer has %s %s", dealerHand.Description, dealerHand.DiceString())
-	a.logAndMaybeShout("Poker Result: "+dealerMessage, dealerMessage)
-
-	resultMessage := comparePokerHands(playerHand, dealerHand)
-	a.logAndMaybeShout("Poker Result: "+resultMessage, resultMessage)
-
-	// Session handling:
-	mutex.Lock()
-	active := session.Active
-	playerName := session.PlayerName
-	item := session.ItemClass
-	bet := session.BetCount
-	mutex.Unlock()
-
-	if active {
-		if pokerPlayerWon(playerHand, dealerHand) {
-			newBal := bet * 2
-			mutex.Lock()
-			session.Balance = newBal
-			session.CanRisk = true
-			session.CanCashOut = true
-			mutex.Unlock()
-
-			// Announce bankroll after win
-			a.logAndMaybeShout("Session update",
-				fmt.Sprintf("%s now has %d %s. Use :risk or :cashout.", playerName, newBal, item))
-		} else {
-			a.AddLogMsg("Session ended: player lost the round.")
-			endSession()
-		}
-	}
-
-	isPokerRolling = false
+// logAndSayResult is logAndSay for a game result, it returns the text said.
+// Results of an older round still queued (during a mute) are dropped for
+// newer ones.
+func (a *App) logAndSayResult(game string, key string, vars msgVars) string {
+	text := renderMessage(key, vars)
+	a.logAndQueue(game+" Result: "+text, routeChat(messageType(key), text, vars["player"], table.Round()))
+	return text
 }
 
-func (a *App) evaluateBlackjackHand() {
-	mutex.Lock()
-	mutex.Unlock()
-
-	if !ChatIsDisabled {
-		// Log the current sum for debugging purposes
-		log.Printf("Evaluating hand: Current sum = %d\n", currentSum)
-
-		// If sum is less than 15, call hitBjDice to roll another dice
-		if currentSum < 15 {
-			log.Println("Sum is less than 15. Hitting another dice.")
-			a.hitBjDice() // This will hit the dice and then re-evaluate the hand
-			return        // Return early after hitting, so we don't send a message yet
-		}
-
-		// Convert sum to string and send to chat
-		hand := strconv.Itoa(currentSum)
-		logRollResult := fmt.Sprintf("21 Result: %s\n", hand)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-
-		if !isMuted {
-			// If the user is not muted, send the message
-			sendMessageWithDelay(hand)
-		} else {
-			// If the user is muted, queue the message to send later
-			log.Printf("User is muted. Queuing message: %s", hand)
-			// ToDo:
-			// messageQueue = append(messageQueue, hand)
-		}
-	} else {
-		// Log the current sum for debugging purposes
-		log.Printf("Evaluating hand: Current sum = %d\n", currentSum)
-
-		// If sum is less than 15, call hitBjDice to roll another dice
-		if currentSum < 15 {
-			log.Println("Sum is less than 15. Hitting another dice.")
-			a.hitBjDice() // This will hit the dice and then re-evaluate the hand
-			return        // Return early after hitting, so we don't send a message yet
-		}
-
-		// Convert sum to string and send to chat
-		hand := strconv.Itoa(currentSum)
-		logRollResult := fmt.Sprintf("21 Result: %s\n", hand)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-	}
-	isBJRolling = false
-	isHitting = false
-}
-
-func (a *App) evaluate13Hand() {
-	mutex.Lock()
-	mutex.Unlock()
-
-	if !ChatIsDisabled {
-		// Log the current sum for debugging purposes
-		log.Printf("Evaluating hand: Current sum = %d\n", currentSum)
-
-		// If sum is less than 15, call hitBjDice to roll another dice
-		if currentSum < 7 {
-			log.Println("Sum is less than 7. Hitting another dice.")
-			a.hit13Dice() // This will hit the dice and then re-evaluate the hand
-			return        // Return early after hitting, so we don't send a message yet
-		}
-
-		// Convert sum to string and send to chat
-		hand := strconv.Itoa(currentSum)
-		logRollResult := fmt.Sprintf("13 Result: %s\n", hand)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-		if !isMuted {
-			// If the user is not muted, send the message
-			sendMessageWithDelay(hand)
-		} else {
-			// If the user is muted, queue the message to send later
-			log.Printf("User is muted. Queuing message: %s", hand)
-			// ToDo:
-			// messageQueue = append(messageQueue, hand)
-		}
-	} else {
-		// Log the current sum for debugging purposes
-		log.Printf("Evaluating hand: Current sum = %d\n", currentSum)
-
-		// If sum is less than 15, call hitBjDice to roll another dice
-		if currentSum < 7 {
-			log.Println("Sum is less than 7. Hitting another dice.")
-			a.hit13Dice() // This will hit the dice and then re-evaluate the hand
-			return        // Return early after hitting, so we don't send a message yet
-		}
-
-		// Convert sum to string and send to chat
-		hand := strconv.Itoa(currentSum)
-		logRollResult := fmt.Sprintf("13 Result: %s\n", hand)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-	}
-	is13Rolling = false
-	is13Hitting = false
-}
-
-// Wait for all dice results and evaluate the tri hand
-func (a *App) evaluateTriHand() {
-	if !ChatIsDisabled {
-		hand := sumHand([]int{
-			diceList[0].Value,
-			diceList[2].Value,
-			diceList[4].Value,
-		})
-		logRollResult := fmt.Sprintf("Tri Result: %s\n", hand)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-
-		if !isMuted {
-			// If the user is not muted, send the message
-			sendMessageWithDelay(hand)
-		} else {
-			// If the user is muted, queue the message to send later
-			log.Printf("User is muted. Queuing message: %s", hand)
-			// ToDo:
-			// messageQueue = append(messageQueue, hand)
-		}
-	} else {
-		hand := sumHand([]int{
-			diceList[0].Value,
-			diceList[2].Value,
-			diceList[4].Value,
-		})
-		logRollResult := fmt.Sprintf("Tri Result: %s\n", hand)
-		time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
-		a.AddLogMsg(logRollResult)
-	}
-	isTriRolling = false
-}
-
-func (a *App) logAndMaybeShout(logMessage string, chatMessage string) {
-	time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
+func (a *App) logAndQueue(logMessage string, msg queuedChat) {
+	activeTiming().PreAnnounce.Sleep()
 	a.AddLogMsg(fmt.Sprintf("%s\n", logMessage))
-	if ChatIsDisabled {
+	if chatDisabled.Load() {
 		return
 	}
-	if isMuted {
-		log.Printf("User is muted. Queuing message: %s", chatMessage)
-		return
+	if mute.Muted() {
+		log.Printf("User is muted. Queuing message: %s", msg.text)
 	}
-	sendMessageWithDelay(chatMessage)
+	chatQueue.Push(msg)
 }
 
 // Sum the values of the dice and return a string representation
@@ -272,26 +57,16 @@ func sumHandInt(values []int) int {
 // Evaluate the hand of dice and return a string representation
 // thank you b7 <3 (and me, eduard, selfplug lol)
 func (a *App) toPokerString(dices []*Dice) string {
-	hand := a.toPokerHandResult(dices)
-	return hand.Description
-}
-
-type PokerHandResult struct {
-	Rank        int
-	Description string
-	Tiebreakers []int
-	DiceValues  []int
-}
-
-func (p PokerHandResult) DiceString() string {
-	parts := make([]string, 0, len(p.DiceValues))
-	for _, value := range p.DiceValues {
-		parts = append(parts, strconv.Itoa(value))
+	values := make([]int, 0, len(dices))
+	for _, dice := range dices {
+		values = append(values, dice.Value)
 	}
-	return fmt.Sprintf("[%s]", strings.Join(parts, ","))
+	hand := a.toPokerHandResult(values)
+	return hand.Description
 }
 
-func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
+// Evaluate the poker hand for the thrown dice values
+func (a *App) toPokerHandResult(diceValues []int) Hand {
 	// Load user configuration
 	config := a.LoadConfig()
 
@@ -314,10 +89,6 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 		}
 	}
 
-	diceValues := make([]int, 0, len(dices))
-	for _, dice := range dices {
-		diceValues = append(diceValues, dice.Value)
-	}
 	s := ""
 	for _, value := range diceValues {
 		s += strconv.Itoa(value)
@@ -329,16 +100,16 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 	s = string(runes)
 
 	if s == "12345" {
-		return PokerHandResult{
-			Rank:        4,
+		return Hand{
+			Score:       4,
 			Description: fmt.Sprintf(config.LowStraight),
 			Tiebreakers: []int{5},
 			DiceValues:  diceValues,
 		}
 	}
 	if s == "23456" {
-		return PokerHandResult{
-			Rank:        4,
+		return Hand{
+			Score:       4,
 			Description: fmt.Sprintf(config.HighStraight),
 			Tiebreakers: []int{6},
 			DiceValues:  diceValues,
@@ -362,8 +133,8 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 	if len(keys) == 0 {
 		tiebreakers := append([]int{}, diceValues...)
 		sort.Slice(tiebreakers, func(i, j int) bool { return tiebreakers[i] > tiebreakers[j] })
-		return PokerHandResult{
-			Rank:        0,
+		return Hand{
+			Score:       0,
 			Description: fmt.Sprintf(config.Nothing),
 			Tiebreakers: tiebreakers,
 			DiceValues:  diceValues,
@@ -378,23 +149,23 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 
 	switch c {
 	case "5":
-		return PokerHandResult{
-			Rank:        7,
+		return Hand{
+			Score:       7,
 			Description: fmt.Sprintf(config.FiveOfAKind, formatKindSuffix(keys[0])),
 			Tiebreakers: []int{keys[0]},
 			DiceValues:  diceValues,
 		}
 	case "4":
-		return PokerHandResult{
-			Rank:        6,
+		return Hand{
+			Score:       6,
 			Description: fmt.Sprintf(config.FourOfAKind, formatKindSuffix(keys[0])),
 			Tiebreakers: []int{keys[0]},
 			DiceValues:  diceValues,
 		}
 	case "3":
 		kickers := kickersForCount(mapCount, 3)
-		return PokerHandResult{
-			Rank:        3,
+		return Hand{
+			Score:       3,
 			Description: fmt.Sprintf(config.ThreeOfAKind, formatKindSuffix(keys[0])),
 			Tiebreakers: append([]int{keys[0]}, kickers...),
 			DiceValues:  diceValues,
@@ -413,8 +184,8 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 
 		// Construct the string with the three-of-a-kind first
 		n = formatKindSuffix(threeOfAKind) + formatKindSuffix(pair)
-		return PokerHandResult{
-			Rank:        5,
+		return Hand{
+			Score:       5,
 			Description: fmt.Sprintf(config.FullHouse, n),
 			Tiebreakers: []int{threeOfAKind, pair},
 			DiceValues:  diceValues,
@@ -423,8 +194,8 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 		pairs := orderedPairs(mapCount)
 		n = formatKindSuffix(pairs[0]) + formatKindSuffix(pairs[1])
 		kicker := kickerForPairs(mapCount, pairs)
-		return PokerHandResult{
-			Rank:        2,
+		return Hand{
+			Score:       2,
 			Description: fmt.Sprintf(config.TwoPair, n),
 			Tiebreakers: []int{pairs[0], pairs[1], kicker},
 			DiceValues:  diceValues,
@@ -432,8 +203,8 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 	case "2":
 		pairValue := keys[0]
 		kickers := kickersForCount(mapCount, 2)
-		return PokerHandResult{
-			Rank:        1,
+		return Hand{
+			Score:       1,
 			Description: fmt.Sprintf(config.OnePair, formatKindSuffix(pairValue)),
 			Tiebreakers: append([]int{pairValue}, kickers...),
 			DiceValues:  diceValues,
@@ -441,8 +212,8 @@ func (a *App) toPokerHandResult(dices []*Dice) PokerHandResult {
 	default:
 		tiebreakers := append([]int{}, diceValues...)
 		sort.Slice(tiebreakers, func(i, j int) bool { return tiebreakers[i] > tiebreakers[j] })
-		return PokerHandResult{
-			Rank:        0,
+		return Hand{
+			Score:       0,
 			Description: n + "",
 			Tiebreakers: tiebreakers,
 			DiceValues:  diceValues,
@@ -485,32 +256,16 @@ func kickersForCount(counts map[int]int, target int) []int {
 	return kickers
 }
 
-func comparePokerHands(player PokerHandResult, dealer PokerHandResult) string {
-	if player.Rank != dealer.Rank {
-		if player.Rank > dealer.Rank {
-			return fmt.Sprintf("%s beats %s, Player wins.", rankName(player.Rank), rankName(dealer.Rank))
-		}
-		return fmt.Sprintf("%s beats %s, Dealer wins.", rankName(dealer.Rank), rankName(player.Rank))
-	}
-
-	// Same rank: compare tiebreakers
-	for i := 0; i < len(player.Tiebreakers) && i < len(dealer.Tiebreakers); i++ {
-		if player.Tiebreakers[i] == dealer.Tiebreakers[i] {
-			continue
-		}
-		if player.Tiebreakers[i] > dealer.Tiebreakers[i] {
-			return fmt.Sprintf("%s beats %s, Player wins.", rankName(player.Rank), rankName(dealer.Rank))
-		}
-		return fmt.Sprintf("%s beats %s, Dealer wins.", rankName(dealer.Rank), rankName(player.Rank))
-	}
-
-	return "Tie game."
+// pokerHand evaluates a poker hand and names it by rank for comparisons
+func (a *App) pokerHand(values []int) Hand {
+	hand := a.toPokerHandResult(values)
+	hand.Rank = rankName(hand.Score)
+	return hand
 }
-
-func pokerPlayerWon(player PokerHandResult, dealer PokerHandResult) bool {
+func pokerPlayerWon(player Hand, dealer Hand) bool {
 	// Strict win check (not tie)
-	if player.Rank != dealer.Rank {
-		return player.Rank > dealer.Rank
+	if player.Score != dealer.Score {
+		return player.Score > dealer.Score
 	}
 	for i := 0; i < len(player.Tiebreakers) && i < len(dealer.Tiebreakers); i++ {
 		if player.Tiebreakers[i] == dealer.Tiebreakers[i] {
@@ -521,23 +276,21 @@ func pokerPlayerWon(player PokerHandResult, dealer PokerHandResult) bool {
 	return false
 }
 
-func rankName(rank int) string {
-	switch rank {
-	case 7:
-		return "Five of a Kind"
-	case 6:
-		return "Four of a Kind"
-	case 5:
-		return "Full House"
-	case 4:
-		return "Straight"
-	case 3:
-		return "Three of a Kind"
-	case 2:
-		return "Two Pair"
-	case 1:
-		return "Pair"
+func pokerOutcome(player Hand, dealer Hand) roundOutcome {
+	switch {
+	case pokerPlayerWon(player, dealer):
+		return outcomeWin
+	case pokerPlayerWon(dealer, player):
+		return outcomeLose
 	default:
-		return "High Card"
+		return outcomePush
+	}
+}
+
+// rankName names a poker rank in the chat language
+func rankName(rank int) string {
+	if rank < 0 || rank >= len(rankKeys) {
+		rank = 0
 	}
+	return localText(chatLanguage(), rankKeys[rank])
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// handleInterference deals with a booth dice that changed during a game
+// without the bot throwing it, usually a bystander rolling it. The change is
+// flagged in the log and the GUI, then the game is paused or voided as set
+// in the interference policy.
+func (a *App) handleInterference(diceID int, previous int, value int) {
+	game := table.Game()
+	message := fmt.Sprintf("Dice %d changed from %d to %d during %s without the bot throwing it", diceID, previous, value, game)
+	a.AddLogMsg("WARNING: " + message)
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "interference", message)
+	}
+
+	mutex.Lock()
+	if session.Active {
+		recordSession(session, "interference", message)
+	}
+	mutex.Unlock()
+
+	switch currentSettings().InterferencePolicy {
+	case "pause":
+		if err := table.Pause(message); err != nil {
+			return
+		}
+		a.AddLogMsg(game + " paused. Use :resume to carry on or :void to void it.")
+	case "void":
+		if err := table.Void(fmt.Sprintf("dice %d was thrown by someone else", diceID)); err != nil {
+			return
+		}
+	}
+}
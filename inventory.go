@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	g "xabbo.b7c.io/goearth"
+	"xabbo.b7c.io/goearth/shockwave/out"
+)
+
+// How long a hand refresh waits for the next STRIPINFO page before it
+// keeps what it has
+const inventoryPageTimeout = 1500 * time.Millisecond
+
+// Most pages one refresh asks for, in case the server never repeats a page
+const maxInventoryPages = 50
+
+// handInventory models the dealer's hand item by item. A full refresh pages
+// through GETSTRIP new/next until the hand wraps around, after that trades,
+// placements and server removals keep it current. Another refresh is only
+// asked for when the model drifts from what the server says.
+//
+// Items are keyed by their decoded strip id, the number TRADE_ITEMS,
+// PLACESTUFF and REMOVESTRIPITEM carry.
+type handInventory struct {
+	// Strip ids by furni class
+	ids map[string][]int
+	// Each id as the page wrote it, what TRADE_ADDITEM takes
+	wire map[int]string
+	// Items received in a trade whose strip ids no page has shown yet
+	pending map[string]int
+	// Ids already taken out, so the server confirming a removal isn't drift
+	gone  map[int]bool
+	ready bool
+
+	// Full refresh in progress and the classes its pages showed so far
+	refreshing bool
+	staged     map[string][]int
+	stagedWire map[int]string
+	pages      int
+	// Any page came back, an empty hand sends one without a class
+	answered bool
+	// Bumped on every page so the page timeout knows the pages went quiet
+	seq int
+	// The hand changed during the refresh, after pages were already taken
+	again bool
+	// Run once the refresh is over
+	waiting []func()
+}
+
+func newHandInventory() handInventory {
+	return handInventory{
+		ids:     map[string][]int{},
+		wire:    map[int]string{},
+		pending: map[string]int{},
+		gone:    map[int]bool{},
+	}
+}
+
+var (
+	hand   = newHandInventory()
+	handMu sync.Mutex
+)
+
+// handReady reports whether a full refresh has completed
+func handReady() bool {
+	handMu.Lock()
+	defer handMu.Unlock()
+	return hand.ready
+}
+
+// inventoryStock counts the dealer's hand items by class, traded items
+// whose ids aren't known yet included
+func inventoryStock() map[string]int {
+	handMu.Lock()
+	defer handMu.Unlock()
+	stock := make(map[string]int, len(hand.ids)+len(hand.pending))
+	for class, ids := range hand.ids {
+		stock[class] = len(ids)
+	}
+	for class, n := range hand.pending {
+		stock[class] += n
+	}
+	return stock
+}
+
+// stripIDsFor returns a copy of the hand item ids we hold for itemClass
+func stripIDsFor(itemClass string) []int {
+	handMu.Lock()
+	defer handMu.Unlock()
+	return append([]int(nil), hand.ids[itemClass]...)
+}
+
+// wireStripID is a strip id as the hand pages wrote it
+func wireStripID(id int) string {
+	handMu.Lock()
+	defer handMu.Unlock()
+	if text, ok := hand.wire[id]; ok {
+		return text
+	}
+	return strconv.Itoa(id)
+}
+
+// decodeHandPage reads the class and strip ids of a STRIPINFO page. The
+// ids are B64 encoded, wire maps each decoded id back to its text.
+func decodeHandPage(raw string) (class string, ids []int, wire map[int]string) {
+	class = itemClassFromHH(raw)
+	if class == "" {
+		return "", nil, nil
+	}
+	wire = map[int]string{}
+	for _, text := range stripIDsBeforeHH(raw, class) {
+		id, err := decodeB64(text)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		wire[id] = text
+	}
+	return class, ids, wire
+}
+
+// take removes one strip id from the model. Callers must hold handMu.
+func (h *handInventory) take(id int) bool {
+	for class, ids := range h.ids {
+		for i, have := range ids {
+			if have != id {
+				continue
+			}
+			h.ids[class] = append(ids[:i:i], ids[i+1:]...)
+			if len(h.ids[class]) == 0 {
+				delete(h.ids, class)
+			}
+			delete(h.wire, id)
+			h.gone[id] = true
+			return true
+		}
+	}
+	return false
+}
+
+// startRefresh resets the staged pages for a new refresh. It returns false
+// when one is already running. Callers must hold handMu.
+func (h *handInventory) startRefresh() bool {
+	if h.refreshing {
+		if h.pages > 0 {
+			h.again = true
+		}
+		return false
+	}
+	h.refreshing = true
+	h.staged = map[string][]int{}
+	h.stagedWire = map[int]string{}
+	h.pages = 0
+	h.answered = false
+	h.seq++
+	return true
+}
+
+// stagePage takes one page of a refresh. It returns true when the next page
+// should be asked for, false when the hand wrapped around, came back empty
+// or the page cap was reached. Callers must hold handMu.
+func (h *handInventory) stagePage(class string, ids []int, wire map[int]string) bool {
+	h.answered = true
+	if _, seen := h.staged[class]; class == "" || seen || h.pages >= maxInventoryPages {
+		return false
+	}
+	h.staged[class] = ids
+	for id, text := range wire {
+		h.stagedWire[id] = text
+	}
+	h.pages++
+	h.seq++
+	return true
+}
+
+// commitRefresh replaces the model with the staged pages. Callers must hold
+// handMu.
+func (h *handInventory) commitRefresh() {
+	h.refreshing = false
+	if h.answered {
+		h.ids = h.staged
+		h.wire = h.stagedWire
+		h.pending = map[string]int{}
+		h.gone = map[int]bool{}
+		h.ready = true
+	}
+	h.staged = nil
+	h.stagedWire = nil
+}
+
+// refreshInventory asks the server for the whole hand. It returns at once,
+// the pages are taken in by handleInventory.
+func (a *App) refreshInventory(reason string) {
+	handMu.Lock()
+	started := hand.startRefresh()
+	seq := hand.seq
+	handMu.Unlock()
+	if !started {
+		return
+	}
+
+	a.AddLogMsg("Inventory: refreshing, " + reason)
+	a.ext.Send(out.GETSTRIP, []byte("AAnew"))
+	a.watchInventoryPage(seq)
+}
+
+// watchInventoryPage ends the refresh when no page follows page seq
+func (a *App) watchInventoryPage(seq int) {
+	time.AfterFunc(inventoryPageTimeout, func() {
+		handMu.Lock()
+		quiet := hand.refreshing && hand.seq == seq
+		handMu.Unlock()
+		if quiet {
+			a.finishRefresh()
+		}
+	})
+}
+
+// finishRefresh replaces the model with the pages the refresh collected
+func (a *App) finishRefresh() {
+	handMu.Lock()
+	if !hand.refreshing {
+		handMu.Unlock()
+		return
+	}
+	hand.commitRefresh()
+	again := hand.again
+	hand.again = false
+	waiting := hand.waiting
+	hand.waiting = nil
+	items := 0
+	for _, ids := range hand.ids {
+		items += len(ids)
+	}
+	classes := len(hand.ids)
+	ready := hand.ready
+	handMu.Unlock()
+
+	if ready {
+		a.AddLogMsg(fmt.Sprintf("Inventory: %d items in %d classes", items, classes))
+		a.emitLedgerUpdate()
+	} else {
+		a.AddLogMsg("Inventory: the server didn't answer GETSTRIP")
+	}
+	for _, fn := range waiting {
+		fn()
+	}
+	if ready && again {
+		a.refreshInventory("the hand changed during the last refresh")
+	}
+}
+
+// afterRefresh refreshes the hand and runs fn once the refresh is over,
+// whether or not the server answered
+func (a *App) afterRefresh(reason string, fn func()) {
+	handMu.Lock()
+	hand.waiting = append(hand.waiting, fn)
+	handMu.Unlock()
+	a.refreshInventory(reason)
+}
+
+// readStripID reads the strip id of REMOVESTRIPITEM, sent as a decimal
+// string or a VL64 int
+func readStripID(data []byte) (int, bool) {
+	text := strings.TrimSpace(strings.TrimRight(string(data), "\x02"))
+	if id, err := strconv.Atoi(text); err == nil {
+		return id, true
+	}
+	id, err := newWireReader(data).readInt()
+	return id, err == nil
+}
+
+// handleInventory keeps the hand model current. It is called from
+// InterceptAll and never waits on the server.
+func (a *App) handleInventory(e *g.Intercept) {
+	h := e.Packet.Header
+	if h.Dir == g.Out {
+		switch h.Value {
+		case 90: // PLACESTUFF (Outgoing) "<strip id> <x> <y> ..."
+			fields := strings.Fields(string(e.Packet.Data))
+			if len(fields) == 0 {
+				return
+			}
+			id, err := strconv.Atoi(fields[0])
+			if err != nil {
+				a.refreshInventory("placed furni with an unreadable strip id")
+				return
+			}
+			a.removeHandItems([]int{id}, "placed")
+		case 67: // ADDSTRIPITEM (Outgoing), furni picked up into the hand
+			a.refreshInventory("furni was picked up")
+		}
+		return
+	}
+
+	switch h.Value {
+	case 140, 98: // STRIPINFO_2, STRIPINFO (Incoming)
+		a.handleHandPage(string(e.Packet.Data))
+	case 99: // REMOVESTRIPITEM (Incoming)
+		if id, ok := readStripID(e.Packet.Data); ok {
+			a.removeHandItems([]int{id}, "removed")
+		}
+	case 101: // STRIPUPDATED (Incoming)
+		a.refreshInventory("the server says the hand changed")
+	}
+}
+
+// handleHandPage takes one STRIPINFO page, the full list of one class.
+// During a refresh the page is staged and the next one asked for until a
+// class repeats. Outside a refresh it corrects the class in the model.
+func (a *App) handleHandPage(raw string) {
+	class, ids, wire := decodeHandPage(raw)
+
+	handMu.Lock()
+	if hand.refreshing {
+		next := hand.stagePage(class, ids, wire)
+		seq := hand.seq
+		handMu.Unlock()
+
+		if !next {
+			a.finishRefresh()
+			return
+		}
+		a.ext.Send(out.GETSTRIP, []byte("AAnext"))
+		a.watchInventoryPage(seq)
+		return
+	}
+	if class == "" {
+		handMu.Unlock()
+		return
+	}
+
+	expected := len(hand.ids[class]) + hand.pending[class]
+	drift := hand.ready && len(ids) != expected
+	for _, id := range hand.ids[class] {
+		delete(hand.wire, id)
+	}
+	hand.ids[class] = ids
+	for id, text := range wire {
+		hand.wire[id] = text
+	}
+	delete(hand.pending, class)
+	handMu.Unlock()
+
+	a.emitLedgerUpdate()
+	if drift {
+		a.AddLogMsg(fmt.Sprintf("Inventory: %d %s in hand, expected %d", len(ids), class, expected))
+		a.refreshInventory(class + " drifted")
+	}
+}
+
+// removeHandItems takes items that left the hand out of the model. An id
+// the model never held means it drifted, so the hand is refreshed.
+func (a *App) removeHandItems(ids []int, why string) {
+	handMu.Lock()
+	missing := 0
+	for _, id := range ids {
+		if hand.gone[id] {
+			continue
+		}
+		if !hand.take(id) {
+			missing++
+		}
+	}
+	ready := hand.ready
+	handMu.Unlock()
+
+	a.emitLedgerUpdate()
+	if missing > 0 && ready {
+		a.refreshInventory(fmt.Sprintf("%d %s items weren't in the model", missing, why))
+	}
+}
+
+// addTradeItems counts items received in a trade until a page shows their
+// strip ids. If none does in time the hand is refreshed.
+func (a *App) addTradeItems(counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	handMu.Lock()
+	for class, n := range counts {
+		hand.pending[class] += n
+	}
+	handMu.Unlock()
+	a.emitLedgerUpdate()
+
+	time.AfterFunc(2*inventoryPageTimeout, func() {
+		handMu.Lock()
+		unseen := false
+		for class := range counts {
+			if hand.pending[class] > 0 {
+				unseen = true
+			}
+		}
+		handMu.Unlock()
+		if unseen {
+			a.refreshInventory("traded items never showed on a page")
+		}
+	})
+}
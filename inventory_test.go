@@ -0,0 +1,186 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type handPage struct {
+	class string
+	ids   []int
+}
+
+func TestRefreshPages(t *testing.T) {
+	capped := make([]handPage, maxInventoryPages+1)
+	for i := range capped {
+		capped[i] = handPage{class: "class" + strconv.Itoa(i), ids: []int{i}}
+	}
+
+	tests := []struct {
+		name  string
+		pages []handPage
+		// Page whose stagePage returns false, the rest must ask for more
+		stopAt    int
+		want      map[string][]int
+		wantReady bool
+	}{
+		{
+			name: "stops on a repeated class",
+			pages: []handPage{
+				{class: "duck", ids: []int{1, 2}},
+				{class: "throne", ids: []int{3}},
+				{class: "duck", ids: []int{1, 2}},
+			},
+			stopAt:    2,
+			want:      map[string][]int{"duck": {1, 2}, "throne": {3}},
+			wantReady: true,
+		},
+		{
+			name:      "empty hand",
+			pages:     []handPage{{class: ""}},
+			stopAt:    0,
+			want:      map[string][]int{},
+			wantReady: true,
+		},
+		{
+			name:      "page cap",
+			pages:     capped,
+			stopAt:    maxInventoryPages,
+			wantReady: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHandInventory()
+			if !h.startRefresh() {
+				t.Fatal("startRefresh() = false on an idle hand")
+			}
+			if h.startRefresh() {
+				t.Fatal("startRefresh() = true during a refresh")
+			}
+			for i, page := range tt.pages {
+				next := h.stagePage(page.class, page.ids, nil)
+				if next != (i < tt.stopAt) {
+					t.Fatalf("stagePage(%q) on page %d = %t", page.class, i, next)
+				}
+				if !next {
+					break
+				}
+			}
+			h.commitRefresh()
+
+			if h.refreshing || h.ready != tt.wantReady {
+				t.Fatalf("refreshing = %t, ready = %t, want false, %t", h.refreshing, h.ready, tt.wantReady)
+			}
+			if tt.want != nil && !reflect.DeepEqual(h.ids, tt.want) {
+				t.Errorf("ids = %v, want %v", h.ids, tt.want)
+			}
+			if tt.want == nil && len(h.ids) != maxInventoryPages {
+				t.Errorf("%d classes kept, want %d", len(h.ids), maxInventoryPages)
+			}
+		})
+	}
+}
+
+func TestRefreshWithoutAnswer(t *testing.T) {
+	h := newHandInventory()
+	h.ids["duck"] = []int{1}
+	h.startRefresh()
+	h.commitRefresh()
+	if h.ready || !reflect.DeepEqual(h.ids, map[string][]int{"duck": {1}}) {
+		t.Errorf("unanswered refresh changed the hand: ready = %t, ids = %v", h.ready, h.ids)
+	}
+}
+
+func TestDecodeHandPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		wantClass string
+		wantIDs   []int
+		wantWire  map[int]string
+	}{
+		{
+			name:      "several items",
+			raw:       "SI\x02MjGl|MjGn|MjGHS\x02i\\wBHHduck\x02",
+			wantClass: "duck",
+			wantIDs:   []int{3580396, 3580398, 3580360},
+			wantWire:  map[int]string{3580396: "MjGl", 3580398: "MjGn", 3580360: "MjGH"},
+		},
+		{
+			name: "empty hand",
+			raw:  "\x02",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, ids, wire := decodeHandPage(tt.raw)
+			if class != tt.wantClass || !reflect.DeepEqual(ids, tt.wantIDs) || !reflect.DeepEqual(wire, tt.wantWire) {
+				t.Errorf("decodeHandPage() = %q, %v, %v, want %q, %v, %v",
+					class, ids, wire, tt.wantClass, tt.wantIDs, tt.wantWire)
+			}
+		})
+	}
+}
+
+func TestStripIDsBeforeHH(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		class string
+		want  []string
+	}{
+		{
+			name:  "single item",
+			raw:   "SI\x02MjGHS\x02i\\wBHHduck\x02",
+			class: "duck",
+			want:  []string{"MjGH"},
+		},
+		{
+			name:  "several items",
+			raw:   "SI\x02MjGl|MjGn|MjGo|MjGHS\x02i\\wBHHduck\x02",
+			class: "duck",
+			want:  []string{"MjGl", "MjGn", "MjGo", "MjGH"},
+		},
+		{
+			name:  "wall item",
+			raw:   "...MjGl|MjGnI\x02i\\wBHHposter\x02",
+			class: "poster",
+			want:  []string{"MjGl", "MjGn"},
+		},
+		{
+			name:  "other class",
+			raw:   "SI\x02MjGHS\x02i\\wBHHduck\x02",
+			class: "throne",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripIDsBeforeHH(tt.raw, tt.class); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stripIDsBeforeHH() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeB64(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "@", want: 0},
+		{in: "A", want: 1},
+		{in: "MjGl", want: 3580396},
+		{in: "", wantErr: true},
+		{in: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := decodeB64(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("decodeB64(%q) = %d, %v, want %d (error %t)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// English is built in, other languages are lang_<code>.json files in the
+// URTBOT config directory. Example lang_nl.json:
+//
+//	{
+//	  "name": "Nederlands",
+//	  "messages": {
+//	    "hand_player": "Speler heeft {player_hand} {dice}",
+//	    "rank_full_house": "Full House"
+//	  }
+//	}
+//
+// Messages use the message template keys, the rank_* and help_* keys below
+// and help_game_<command> for the help of each game. Any key a pack leaves
+// out is said in English.
+const defaultLanguage = "en"
+
+// Poker hand names by rank, as said in comparisons
+var rankKeys = []string{
+	"rank_high_card",
+	"rank_pair",
+	"rank_two_pair",
+	"rank_three_of_a_kind",
+	"rank_straight",
+	"rank_full_house",
+	"rank_four_of_a_kind",
+	"rank_five_of_a_kind",
+}
+
+// English text of the keys that aren't message templates
+var englishText = map[string]string{
+	"rank_high_card":       "High Card",
+	"rank_pair":            "Pair",
+	"rank_two_pair":        "Two Pair",
+	"rank_three_of_a_kind": "Three of a Kind",
+	"rank_straight":        "Straight",
+	"rank_full_house":      "Full House",
+	"rank_four_of_a_kind":  "Four of a Kind",
+	"rank_five_of_a_kind":  "Five of a Kind",
+
+	"help_intro":      "Thanks for using my plugin!\nBelow is it's list of commands. ",
+	"help_reset":      "Forgets dice list for when you\nchange booth.",
+	"help_close":      "Closes any of your open dice. ",
+	"help_void":       "Voids the game being played, then\nreplays it or refunds the bet.",
+	"help_resume":     "Carries on a game paused after\nsomeone else threw a booth dice.",
+	"help_calibrate":  "Learns this hotel's dice value\nencoding from the last ignored\nresult and the face it showed.",
+	"help_verify":     "Will say the previous result in\nchat. Use if you were muted and\ndont know the results of 21/13.",
+	"help_chaton":     "Enables chat announcement \nof game results. ",
+	"help_chatoff":    "Disables chat announcement \nof game results. ",
+	"help_at":         "Stores @ amount in roll log \nwith the result and will announce \nit in chat. ",
+	"help_session":    "Manual test: starts a session.",
+	"help_lang":       "Sets the session player's\nlanguage. Players can say\n:lang <code> too.",
+	"help_endsession": "Ends the current session.",
+	"help_cashout":    "Pays the session balance to the\nplayer by trade, then ends it.",
+	"help_risk":       "Puts the session balance back in\nplay, double or nothing.",
+	"help_commands":   "This help screen :)",
+}
+
+// LanguagePack is one lang_<code>.json file
+type LanguagePack struct {
+	Name     string            `json:"name"`
+	Messages map[string]string `json:"messages"`
+}
+
+// LanguageInfo describes a loaded language in the GUI
+type LanguageInfo struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Keys the pack leaves to English
+	Missing int `json:"missing"`
+}
+
+var (
+	languagePacks = map[string]LanguagePack{}
+	// Language chosen by the session player, empty for the default
+	sessionLanguage string
+	languageMu      sync.RWMutex
+)
+
+// loadLanguagePacks reads every lang_*.json file in the config directory.
+// Keys that aren't known or don't validate are logged and left to English.
+func (a *App) loadLanguagePacks() {
+	files, err := filepath.Glob(configFilePath("lang_*.json"))
+	if err != nil {
+		a.AddLogMsg("Error listing language packs: " + err.Error())
+		return
+	}
+	sort.Strings(files)
+
+	loaded := map[string]LanguagePack{}
+	for _, file := range files {
+		code := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "lang_"), ".json"))
+		if code == "" || code == defaultLanguage {
+			a.AddLogMsg(fmt.Sprintf("Skipping language pack %s: English is built in", filepath.Base(file)))
+			continue
+		}
+		pack, err := readLanguagePack(file)
+		if err != nil {
+			a.AddLogMsg(fmt.Sprintf("Skipping language pack %s: %v", filepath.Base(file), err))
+			continue
+		}
+		for key, text := range pack.Messages {
+			if err := validateTranslation(key, text); err != nil {
+				a.AddLogMsg(fmt.Sprintf("Language %s: %v, using English", code, err))
+				delete(pack.Messages, key)
+			}
+		}
+		if pack.Name == "" {
+			pack.Name = code
+		}
+		loaded[code] = pack
+		a.AddLogMsg(fmt.Sprintf("Loaded language %s (%s), %d keys in English", pack.Name, code, missingKeys(pack)))
+	}
+
+	languageMu.Lock()
+	languagePacks = loaded
+	languageMu.Unlock()
+}
+
+func readLanguagePack(path string) (LanguagePack, error) {
+	var pack LanguagePack
+	file, err := os.Open(path)
+	if err != nil {
+		return pack, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&pack); err != nil {
+		return pack, err
+	}
+	if pack.Messages == nil {
+		pack.Messages = map[string]string{}
+	}
+	return pack, nil
+}
+
+// validateTranslation checks a pack key is known and its text is usable
+func validateTranslation(key string, text string) error {
+	if _, ok := findTemplateDef(key); ok {
+		return validateTemplate(key, text)
+	}
+	_, known := englishText[key]
+	if !known && strings.HasPrefix(key, "help_game_") {
+		known = gameForCommand(strings.TrimPrefix(key, "help_game_")) != nil
+	}
+	if !known {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%s can't be empty", key)
+	}
+	return nil
+}
+
+// translationKeys lists every key a pack can translate
+func translationKeys() []string {
+	keys := make([]string, 0, len(templateDefs)+len(englishText)+len(games))
+	for _, def := range templateDefs {
+		keys = append(keys, def.key)
+	}
+	for key := range englishText {
+		keys = append(keys, key)
+	}
+	for _, game := range games {
+		keys = append(keys, gameHelpKey(game))
+	}
+	sort.Strings(keys[len(templateDefs):])
+	return keys
+}
+
+func gameHelpKey(game Game) string {
+	return "help_game_" + game.Commands()[0]
+}
+
+func missingKeys(pack LanguagePack) int {
+	missing := 0
+	for _, key := range translationKeys() {
+		if _, ok := pack.Messages[key]; !ok {
+			missing++
+		}
+	}
+	return missing
+}
+
+func knownLanguage(code string) bool {
+	if code == defaultLanguage {
+		return true
+	}
+	languageMu.RLock()
+	defer languageMu.RUnlock()
+	_, ok := languagePacks[code]
+	return ok
+}
+
+// chatLanguage is the language chat is said in: the session player's
+// choice, otherwise the dealer's default
+func chatLanguage() string {
+	languageMu.RLock()
+	lang := sessionLanguage
+	languageMu.RUnlock()
+	if lang != "" {
+		return lang
+	}
+	return currentSettings().Language
+}
+
+// setSessionLanguage changes the language for the current session, empty
+// goes back to the default
+func setSessionLanguage(code string) {
+	languageMu.Lock()
+	sessionLanguage = code
+	languageMu.Unlock()
+}
+
+// translate returns a key's text in a language, false when the language
+// leaves it to English
+func translate(lang string, key string) (string, bool) {
+	languageMu.RLock()
+	defer languageMu.RUnlock()
+	text, ok := languagePacks[lang].Messages[key]
+	return text, ok
+}
+
+// localText returns a key that isn't a message template in a language,
+// falling back to English
+func localText(lang string, key string) string {
+	if text, ok := translate(lang, key); ok {
+		return text
+	}
+	return englishText[key]
+}
+
+// setPlayerLanguage handles a session player's :lang <code>
+func (a *App) setPlayerLanguage(player string, code string) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if !knownLanguage(code) {
+		a.AddLogMsg(fmt.Sprintf("%s asked for unknown language %q", player, code))
+		a.logAndSay("Language", msgLanguageUnknown, msgVars{
+			"player":    player,
+			"languages": languageList(),
+		})
+		return
+	}
+	setSessionLanguage(code)
+	a.AddLogMsg(fmt.Sprintf("%s chose language %s", player, code))
+	a.logAndSay("Language", msgLanguageSet, msgVars{
+		"player":   player,
+		"language": languageName(code),
+	})
+}
+
+func languageName(code string) string {
+	if code == defaultLanguage {
+		return "English"
+	}
+	languageMu.RLock()
+	defer languageMu.RUnlock()
+	return languagePacks[code].Name
+}
+
+// languageList lists the language codes a player can choose from
+func languageList() string {
+	languageMu.RLock()
+	codes := []string{defaultLanguage}
+	for code := range languagePacks {
+		codes = append(codes, code)
+	}
+	languageMu.RUnlock()
+	sort.Strings(codes[1:])
+	return strings.Join(codes, ", ")
+}
+
+// LoadLanguages reloads the language packs and lists them, English first
+func (a *App) LoadLanguages() []LanguageInfo {
+	a.loadLanguagePacks()
+
+	languageMu.RLock()
+	defer languageMu.RUnlock()
+	infos := []LanguageInfo{{Code: defaultLanguage, Name: "English"}}
+	codes := make([]string, 0, len(languagePacks))
+	for code := range languagePacks {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		pack := languagePacks[code]
+		infos = append(infos, LanguageInfo{Code: code, Name: pack.Name, Missing: missingKeys(pack)})
+	}
+	return infos
+}
+
+// ExportLanguageTemplate writes every key with its English text to
+// language_template.json for translators to start from
+func (a *App) ExportLanguageTemplate() {
+	pack := LanguagePack{Name: "English", Messages: map[string]string{}}
+	for _, def := range templateDefs {
+		pack.Messages[def.key] = def.text
+	}
+	for key, text := range englishText {
+		pack.Messages[key] = text
+	}
+	for _, game := range games {
+		pack.Messages[gameHelpKey(game)] = game.Help()
+	}
+
+	path := configFilePath("language_template.json")
+	file, err := os.Create(path)
+	if err != nil {
+		a.AddLogMsg("Error creating language template: " + err.Error())
+		return
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(pack); err != nil {
+		a.AddLogMsg("Error encoding language template: " + err.Error())
+		return
+	}
+	a.AddLogMsg("Language template written to " + path + ", copy it to lang_<code>.json to translate")
+}
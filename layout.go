@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Booth layouts, the shape the dice are placed in around the dealer
+const (
+	LayoutRow      = "row"
+	LayoutArc      = "arc"
+	LayoutPentagon = "pentagon"
+)
+
+// Named booth positions once the dice are sorted by layout. Games roll by
+// position so the formation doesn't depend on the order dice were clicked.
+// Numbers ("1" to "8") address the sorted dice directly.
+const (
+	PosLeft        = "left"
+	PosLeftCenter  = "left-center"
+	PosCenter      = "center"
+	PosRightCenter = "right-center"
+	PosRight       = "right"
+)
+
+func validLayout(layout string) bool {
+	return layout == LayoutRow || layout == LayoutArc || layout == LayoutPentagon
+}
+
+// positionIndex returns the booth index of a position on a booth of n dice
+func positionIndex(pos string, n int) (int, error) {
+	var index int
+	switch pos {
+	case PosLeft:
+		index = 0
+	case PosLeftCenter:
+		index = n / 4
+	case PosCenter:
+		index = n / 2
+	case PosRightCenter:
+		index = n - 1 - n/4
+	case PosRight:
+		index = n - 1
+	default:
+		number, err := strconv.Atoi(pos)
+		if err != nil || number < 1 || number > maxBoothDice {
+			return 0, fmt.Errorf("unknown booth position %q", pos)
+		}
+		index = number - 1
+	}
+	if index < 0 || index >= n {
+		return 0, fmt.Errorf("position %s is outside a booth of %d dice", pos, n)
+	}
+	return index, nil
+}
+
+// positionsNeeded returns the smallest booth on which the positions are all
+// different dice
+func positionsNeeded(positions []string) (int, error) {
+	needed := 0
+	for _, pos := range positions {
+		var n int
+		switch pos {
+		case PosLeft, PosCenter, PosRight:
+			n = 3
+		case PosLeftCenter, PosRightCenter:
+			n = 5
+		default:
+			number, err := strconv.Atoi(pos)
+			if err != nil || number < 1 || number > maxBoothDice {
+				return 0, fmt.Errorf("unknown booth position %q", pos)
+			}
+			n = number
+		}
+		if n > needed {
+			needed = n
+		}
+	}
+	return needed, nil
+}
+
+// resolvePositions maps positions to booth indices on a booth of n dice
+func resolvePositions(positions []string, n int) ([]int, error) {
+	indices := make([]int, 0, len(positions))
+	used := map[int]string{}
+	for _, pos := range positions {
+		index, err := positionIndex(pos, n)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := used[index]; ok {
+			return nil, fmt.Errorf("positions %s and %s are the same dice on a booth of %d", other, pos, n)
+		}
+		used[index] = pos
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// sortByLayout orders booth dice left to right as the dealer sees them.
+// Tiles are projected to screen space first: x runs to the bottom right and
+// y to the bottom left, so screen x is x-y and screen y is x+y.
+//
+//   - row: left to right
+//   - arc: from one end of the arc to the other, starting on the left
+//   - pentagon: clockwise around the center, starting from the leftmost dice
+//
+// Dice without a known position are left in the order they were added.
+func sortByLayout(dices []*Dice, layout string) bool {
+	if len(dices) < 2 {
+		return true
+	}
+	for _, dice := range dices {
+		if !dice.Placed {
+			return false
+		}
+	}
+
+	screenX := func(d *Dice) float64 { return float64(d.X - d.Y) }
+	screenY := func(d *Dice) float64 { return float64(d.X + d.Y) }
+
+	if layout == LayoutRow {
+		sort.SliceStable(dices, func(i, j int) bool {
+			if screenX(dices[i]) != screenX(dices[j]) {
+				return screenX(dices[i]) < screenX(dices[j])
+			}
+			return screenY(dices[i]) < screenY(dices[j])
+		})
+		return true
+	}
+
+	// Angle of every dice around the center of the booth, clockwise on screen
+	var cx, cy float64
+	for _, dice := range dices {
+		cx += screenX(dice)
+		cy += screenY(dice)
+	}
+	cx /= float64(len(dices))
+	cy /= float64(len(dices))
+	angle := map[*Dice]float64{}
+	for _, dice := range dices {
+		angle[dice] = math.Atan2(screenY(dice)-cy, screenX(dice)-cx)
+	}
+	sort.SliceStable(dices, func(i, j int) bool {
+		return angle[dices[i]] < angle[dices[j]]
+	})
+
+	start := 0
+	if layout == LayoutArc {
+		// The arc's open side is the widest gap, the first dice follows it
+		widest := -1.0
+		for i := range dices {
+			next := (i + 1) % len(dices)
+			gap := angle[dices[next]] - angle[dices[i]]
+			if gap <= 0 {
+				gap += 2 * math.Pi
+			}
+			if gap > widest {
+				widest = gap
+				start = next
+			}
+		}
+	} else {
+		for i, dice := range dices {
+			if screenX(dice) < screenX(dices[start]) ||
+				(screenX(dice) == screenX(dices[start]) && screenY(dice) < screenY(dices[start])) {
+				start = i
+			}
+		}
+	}
+
+	rotated := append(append([]*Dice(nil), dices[start:]...), dices[:start]...)
+	copy(dices, rotated)
+
+	// An arc reads left to right whichever way round it was found
+	if layout == LayoutArc && screenX(dices[0]) > screenX(dices[len(dices)-1]) {
+		for i, j := 0, len(dices)-1; i < j; i, j = i+1, j-1 {
+			dices[i], dices[j] = dices[j], dices[i]
+		}
+	}
+	return true
+}
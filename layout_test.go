@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// placed returns dice with ids 1.. on the given tiles
+func placed(tiles ...[2]int) []*Dice {
+	dices := make([]*Dice, 0, len(tiles))
+	for i, tile := range tiles {
+		dices = append(dices, &Dice{ID: i + 1, X: tile[0], Y: tile[1], Placed: true})
+	}
+	return dices
+}
+
+func diceIDs(dices []*Dice) []int {
+	ids := make([]int, 0, len(dices))
+	for _, dice := range dices {
+		ids = append(ids, dice.ID)
+	}
+	return ids
+}
+
+func TestSortByLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+		dices  []*Dice
+		want   []int
+	}{
+		{
+			// Screen x is x-y, the dice with the highest y is the leftmost
+			name:   "row",
+			layout: LayoutRow,
+			dices:  placed([2]int{5, 5}, [2]int{5, 6}, [2]int{5, 4}),
+			want:   []int{2, 1, 3},
+		},
+		{
+			// Open side at the bottom of the screen
+			name:   "arc",
+			layout: LayoutArc,
+			dices:  placed([2]int{8, 12}, [2]int{8, 10}, [2]int{8, 8}, [2]int{10, 8}, [2]int{12, 8}),
+			want:   []int{1, 2, 3, 4, 5},
+		},
+		{
+			// Leftmost first, then clockwise over the top
+			name:   "pentagon",
+			layout: LayoutPentagon,
+			dices:  placed([2]int{8, 12}, [2]int{8, 8}, [2]int{12, 8}, [2]int{13, 11}, [2]int{11, 13}),
+			want:   []int{1, 2, 3, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The order dice were added in must not matter
+			shuffled := append([]*Dice(nil), tt.dices...)
+			rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) {
+				shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+			})
+			if !sortByLayout(shuffled, tt.layout) {
+				t.Fatal("sortByLayout() = false with every dice placed")
+			}
+			if got := diceIDs(shuffled); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortByLayout() order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortByLayoutUnplaced(t *testing.T) {
+	dices := placed([2]int{5, 5}, [2]int{5, 6})
+	dices[1].Placed = false
+	if sortByLayout(dices, LayoutRow) {
+		t.Error("sortByLayout() = true with a dice of unknown position")
+	}
+	if got := diceIDs(dices); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("order changed to %v", got)
+	}
+}
+
+func TestResolvePositions(t *testing.T) {
+	tests := []struct {
+		positions []string
+		n         int
+		want      []int
+		wantErr   bool
+	}{
+		{positions: []string{PosLeft, PosCenter, PosRight}, n: 3, want: []int{0, 1, 2}},
+		{positions: []string{PosLeft, PosCenter, PosRight}, n: 5, want: []int{0, 2, 4}},
+		{positions: []string{PosLeftCenter, PosRightCenter}, n: 5, want: []int{1, 3}},
+		{positions: []string{"1", "5"}, n: 5, want: []int{0, 4}},
+		// left-center is the left dice on a booth of 3
+		{positions: []string{PosLeft, PosLeftCenter}, n: 3, wantErr: true},
+		{positions: []string{"4"}, n: 3, wantErr: true},
+		{positions: []string{"top"}, n: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := resolvePositions(tt.positions, tt.n)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resolvePositions(%v, %d) = %v, %v, want %v (error %t)", tt.positions, tt.n, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPositionsNeeded(t *testing.T) {
+	tests := []struct {
+		positions []string
+		want      int
+	}{
+		{positions: []string{PosLeft, PosRight}, want: 3},
+		{positions: []string{PosCenter, PosRightCenter}, want: 5},
+		{positions: []string{PosLeft, "7"}, want: 7},
+	}
+	for _, tt := range tests {
+		if got, err := positionsNeeded(tt.positions); err != nil || got != tt.want {
+			t.Errorf("positionsNeeded(%v) = %d, %v, want %d", tt.positions, got, err, tt.want)
+		}
+	}
+	if _, err := positionsNeeded([]string{"0"}); err == nil {
+		t.Error("positionsNeeded([0]) gave no error")
+	}
+}
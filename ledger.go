@@ -0,0 +1,161 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Liability is credits the dealer may still have to pay out
+type Liability struct {
+	Source  string `json:"source"`
+	Credits int    `json:"credits"`
+}
+
+// LedgerClass is the dealer's hand for one item class, split between what
+// is reserved for liabilities and what is free to cover new bets
+type LedgerClass struct {
+	Class     string `json:"class"`
+	InHand    int    `json:"in_hand"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+}
+
+// Ledger is what the GUI shows of the liabilities and the hand
+type Ledger struct {
+	Liabilities []Liability   `json:"liabilities"`
+	Classes     []LedgerClass `json:"classes"`
+	// Credits that couldn't be reserved from the hand
+	Shortfall int `json:"shortfall"`
+}
+
+// sessionLiability is the most the session can still cost. A bet in play
+// may win the highest payout of any game. A balance is owed, and its
+// highest payout while the player can still risk it. Callers must hold
+// mutex.
+func sessionLiability(limits BotSettings) (Liability, bool) {
+	if !session.Active {
+		return Liability{}, false
+	}
+	source := "session " + session.PlayerName
+	if payout.Active {
+		return Liability{Source: source + " (" + payout.Kind + ")", Credits: payout.Credits}, true
+	}
+	if session.Balance > 0 {
+		owed := session.Balance
+		canRisk := session.CanRisk &&
+			(limits.MaxRisks <= 0 || session.RiskCount < limits.MaxRisks) &&
+			(limits.MaxBalance <= 0 || winPayout(owed) <= limits.MaxBalance)
+		if canRisk {
+			return Liability{Source: source + " (balance, can risk)", Credits: winPayout(owed)}, true
+		}
+		return Liability{Source: source + " (balance)", Credits: owed}, true
+	}
+	return Liability{Source: source + " (bet in play)", Credits: winPayout(session.Bet)}, true
+}
+
+// liabilities lists what is owed: the session and a bet trade the dealer
+// already accepted. Whoever is asking leaves their own liability out, like
+// a :risk replacing its balance or the accepted trade checking itself.
+func liabilities(includeSession bool, includeTrade bool) []Liability {
+	limits := currentSettings()
+	owed := []Liability{}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if includeTrade && betTrade.Open && betTrade.AcceptedByBot && betTrade.Bet > 0 {
+		owed = append(owed, Liability{Source: "trade with " + betTrade.Partner + " (accepted)", Credits: winPayout(betTrade.Bet)})
+	}
+	if includeSession {
+		if l, ok := sessionLiability(limits); ok {
+			owed = append(owed, l)
+		}
+	}
+	return owed
+}
+
+// reserveStock takes the hand items paying each liability out of stock,
+// highest first. It returns the items reserved by class and the credits no
+// combination of the remaining items could pay.
+func reserveStock(stock map[string]int, owed []Liability) (reserved map[string]int, shortfall int) {
+	sorted := append([]Liability(nil), owed...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Credits > sorted[j].Credits })
+
+	reserved = map[string]int{}
+	for _, l := range sorted {
+		items, ok := planPayout(l.Credits, stock)
+		if !ok {
+			shortfall += l.Credits
+			continue
+		}
+		for class, n := range items {
+			stock[class] -= n
+			reserved[class] += n
+		}
+	}
+	return reserved, shortfall
+}
+
+// freeStock is the hand minus the items reserved for liabilities
+func freeStock(owed []Liability) (map[string]int, int) {
+	stock := inventoryStock()
+	_, shortfall := reserveStock(stock, owed)
+	return stock, shortfall
+}
+
+// canCoverBet reports whether the free hand items can pay a new bet's
+// payout on top of everything already owed
+func canCoverBet(needed int) bool {
+	stock, shortfall := freeStock(liabilities(true, false))
+	if shortfall > 0 {
+		return false
+	}
+	_, ok := planPayout(needed, stock)
+	return ok
+}
+
+// canCoverSession is canCoverBet for the session's own next payout, its
+// current liability is replaced rather than added to
+func canCoverSession(needed int) bool {
+	stock, shortfall := freeStock(liabilities(false, true))
+	if shortfall > 0 {
+		return false
+	}
+	_, ok := planPayout(needed, stock)
+	return ok
+}
+
+// GetLedger returns the liabilities and the hand split into reserved and
+// available items by class
+func (a *App) GetLedger() Ledger {
+	owed := liabilities(true, true)
+	inHand := inventoryStock()
+	stock := make(map[string]int, len(inHand))
+	for class, n := range inHand {
+		stock[class] = n
+	}
+	reserved, shortfall := reserveStock(stock, owed)
+
+	classes := make([]string, 0, len(inHand))
+	for class := range inHand {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	ledger := Ledger{Liabilities: owed, Classes: []LedgerClass{}, Shortfall: shortfall}
+	for _, class := range classes {
+		ledger.Classes = append(ledger.Classes, LedgerClass{
+			Class:     class,
+			InHand:    inHand[class],
+			Reserved:  reserved[class],
+			Available: inHand[class] - reserved[class],
+		})
+	}
+	return ledger
+}
+
+func (a *App) emitLedgerUpdate() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "ledgerUpdate")
+	}
+}
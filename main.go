@@ -6,12 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -21,65 +21,53 @@ import (
 	"xabbo.b7c.io/goearth/shockwave/out"
 )
 
-// Global variables for dice management, rolling state, mutex, and wait group
+// Global variables for chat settings and the shared mutex.
+// Dice and game state live on the table (see table.go), mute state in mute.go.
 var (
-	diceList         []*Dice
-	mutedDuration    int
-	isMuted          bool
-	currentSum       int
-	commandList      string
-	isPokerRolling   bool
-	isTriRolling     bool
-	isBJRolling      bool
-	is13Rolling      bool
-	is13Hitting      bool
-	isHitting        bool
-	isClosing        bool
-	ChatIsDisabled   bool
-	mutex            sync.Mutex
-	resultsWaitGroup sync.WaitGroup
-	rollDelay        = 550 * time.Millisecond
-		// Trade capture (one trade at a time)
-	tradeOpen       bool
-	tradePartner    string
-	tradeItemClass  string
-	tradeBetCount   int
-
-	// Inventory cache built from STRIPINFO_2 after GETSTRIP new
-	invCounts       = map[string]int{}
-	invCollecting   bool
-	invReady		bool
-)
-var (
-	lastStripInfoAt time.Time
+	commandList string
+	// Set by :chatoff, read by every game goroutine
+	chatDisabled atomic.Bool
+	mutex        sync.Mutex
 )
 
-var tradeCanAutoAccept bool
-var tradeNeeded int
-var dealerAddedInTrade int
 var autoTradeAccept = true
-var tradeAcceptedByBot bool
 
 // ---- Session (Step 1) ----
 // One session at a time. Trades will hook into this later.
 type Session struct {
 	Active     bool
+	ID         string
 	PlayerName string
 
-	// What item the player bet (e.g. "duck") and how many were bet for the CURRENT round
-	ItemClass string
-	BetCount  int
+	// What the player traded in (e.g. "2 duck, 1 throne")
+	BetItems string
+	// Credits in play for the CURRENT round
+	Bet int
 
-	// Bankroll tracked AFTER a win (e.g. bet 1 duck => Balance becomes 2)
+	// Bankroll in credits tracked AFTER a win (e.g. bet 5 => Balance becomes 10)
 	Balance int
 
 	// State flags
 	AwaitingGameChoice bool
 	InGame             bool
 
+	// When the player was asked to choose a game, for the choice timeout
+	ChoiceSince    time.Time
+	ChoiceReminded bool
+	ChoiceExpired  bool
+
 	// Post-win options
 	CanRisk    bool
 	CanCashOut bool
+
+	// Number of :risk in a row for this session
+	RiskCount int
+
+	// Times the current round was replayed after a void
+	Replays int
+
+	// Command of the game chosen while muted, started when the mute ends
+	HeldGame string
 }
 
 var session Session
@@ -113,20 +101,20 @@ func NewApp(ext *g.Ext, assets embed.FS) *App {
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.LoadSettings()
+	a.LoadTimingProfiles()
+	a.LoadTemplates()
+	a.LoadItemValues()
+	a.loadGameDefinitions()
+	a.loadLanguagePacks()
+	a.loadSavedBooths()
 	a.setupExt()
+	go a.runChatQueue()
+	go a.runMuteTracker()
 	go func() {
 		a.runExt()
 	}()
-	// Warm inventory continuously so first trade has real counts
-	go func() {
-		time.Sleep(2 * time.Second)
-		for {
-			a.refreshInventoryAndWait(3 * time.Second)
-			time.Sleep(8 * time.Second)
-		}
-	}()
-
-
+	go a.runChoiceTimeouts()
 }
 
 func (a *App) LoadConfig() *PokerDisplayConfig {
@@ -178,10 +166,15 @@ func (a *App) SaveConfig(config *PokerDisplayConfig) {
 }
 
 func getConfigFilePath() string {
+	return configFilePath("poker_display_config.json")
+}
+
+// configFilePath returns the path of name inside the URTBOT config directory
+func configFilePath(name string) string {
 	configDir, _ := os.UserConfigDir()
 	configPath := filepath.Join(configDir, "URTBOT")
 	os.MkdirAll(configPath, 0700)
-	return filepath.Join(configPath, "poker_display_config.json")
+	return filepath.Join(configPath, name)
 }
 
 func (a *App) setupExt() {
@@ -191,10 +184,18 @@ func (a *App) setupExt() {
 	a.ext.Intercept(in.DICE_VALUE).With(a.handleDiceResult)
 	a.ext.Intercept(out.CHAT).With(a.handleTalk)
 	a.ext.Intercept(out.SHOUT).With(a.handleTalk)
+	a.ext.Intercept(in.USERS).With(a.handleUsers)
+	a.ext.Intercept(in.LOGOUT).With(a.handleUserLeave)
+	a.ext.Intercept(in.CHAT, in.CHAT_2, in.CHAT_3).With(a.handleRoomChat)
+	a.ext.Intercept(in.ROOM_READY).With(a.handleRoomReady)
+	a.ext.Intercept(in.ACTIVE_OBJECTS).With(a.handleActiveObjects)
+	a.ext.Intercept(in.ACTIVEOBJECT_ADD, in.ACTIVEOBJECT_UPDATE).With(a.handleActiveObjectUpdate)
+	a.ext.Intercept(in.ACTIVEOBJECT_REMOVE).With(a.handleActiveObjectRemove)
 	a.ext.InterceptAll(func(e *g.Intercept) {
-	handleMutePacket(e)     // existing
-	a.handleTradeAndInv(e)  // new Step 2
-})
+		handleMutePacket(e)    // existing
+		a.handleInventory(e)   // hand model, inventory.go
+		a.handleTradeAndInv(e) // new Step 2
+	})
 	// Register missing identifiers (Shockwave)
 	a.ext.Initialized(func(args g.InitArgs) {
 		// Outgoing[402] -> TRADE_CONFIRM_ACCEPT
@@ -212,81 +213,60 @@ func (a *App) ShowWindow() {
 	runtime.WindowShow(a.ctx)
 }
 
-func startMuteTimer(duration int) {
-	for duration > 0 {
-		log.Printf("Remaining mute time: %d seconds", duration)
-		time.Sleep(1 * time.Second) // Sleep for 1 second
-		duration--
-	}
-
-	// Mute duration finished
-	handleMuteEnd()
-}
-
-func handleMuteEnd() {
-	isMuted = false
-	log.Println("Mute finished, sending queued messages...")
-
-	// ToDo:
-	// // Send all queued messages
-	// for _, message := range messageQueue {
-	// 	sendMessageWithDelay(message)
-	// }
-
-	// // Clear the message queue
-	// messageQueue = []string{}
-}
-
-// Mute detection logic (called within InterceptAll)
-func handleMutePacket(e *g.Intercept) {
-	// Check for the "first muted" packet with header 4069
-	if e.Packet.Header.Value == 4069 {
-		mutedDuration = e.Packet.ReadInt() // Read the mute duration in seconds
-		log.Printf("You are muted for %d seconds.", mutedDuration)
-		isMuted = true
-		go startMuteTimer(mutedDuration) // Start the mute timer
-	}
-
-	// Check for the "trying to chat while muted" packet with header 3285
-	if e.Packet.Header.Value == 3285 {
-		remainingMuteDuration := e.Packet.ReadInt() // Read the remaining mute duration
-		log.Printf("Mute still active, remaining time: %d seconds.", remainingMuteDuration)
-	}
-}
-
 func (a *App) onChatMessage(e *g.Intercept) {
 	msg := e.Packet.ReadString()
 
 	// Process commands based on the message prefix and suffix
 	if strings.HasPrefix(msg, ":") {
+		// :void and :resume work while a game is running
+		switch strings.TrimSpace(msg) {
+		case ":void":
+			e.Block()
+			a.voidCurrentRound()
+			return
+		case ":resume":
+			e.Block()
+			a.resumeRound()
+			return
+		}
+
 		// Check if already rolling or closing
-		if isPokerRolling || isTriRolling || isBJRolling || is13Rolling || isHitting || is13Hitting || isClosing {
-			log.Println("Already rolling or closing...")
+		if table.Busy() {
+			log.Printf("Table is %s (%s)...", table.Phase(), table.Game())
 			e.Block()
 			return
 		}
 
 		command := strings.TrimPrefix(msg, ":")
 		switch {
-					case strings.HasPrefix(command, "session "):
+		case strings.HasPrefix(command, "session "):
 			// Manual test command (Step 1):
-			// :session <playerName> <itemClass> <betCount>
-			// Example: :session bob duck 1
+			// :session <playerName> <itemClass> <betCount> [language]
+			// Example: :session bob duck 1 nl
 			e.Block()
 
-			parts := strings.Fields(command) // ["session","bob","duck","1"]
-			if len(parts) != 4 {
-				a.AddLogMsg("Usage: :session <playerName> <itemClass> <betCount>")
+			parts := strings.Fields(command) // ["session","bob","duck","1","nl"]
+			if len(parts) != 4 && len(parts) != 5 {
+				a.AddLogMsg("Usage: :session <playerName> <itemClass> <betCount> [language]")
+				return
+			}
+			if len(parts) == 5 && !knownLanguage(strings.ToLower(parts[4])) {
+				a.AddLogMsg("Session error: language must be one of " + languageList())
 				return
 			}
 
 			playerName := parts[1]
-			itemClass := parts[2]
+			itemClass := strings.ToLower(parts[2])
 			n, err := strconv.Atoi(parts[3])
 			if err != nil || n <= 0 {
 				a.AddLogMsg("Session error: betCount must be a positive number.")
 				return
 			}
+			value, ok := itemValue(itemClass)
+			if !ok {
+				a.AddLogMsg(fmt.Sprintf("Session error: %s has no value in the item catalog.", itemClass))
+				return
+			}
 
 			// Don’t allow overriding an active session
 			if sessionActive() {
@@ -294,9 +274,12 @@ func (a *App) onChatMessage(e *g.Intercept) {
 				return
 			}
 
-			startSession(playerName, itemClass, n)
-			a.AddLogMsg(fmt.Sprintf("Session started for %s: %dx %s. Awaiting game choice (:pkr, :tri, :21, :13)",
-				playerName, n, itemClass))
+			startSession(playerName, describeItems(map[string]int{itemClass: n}), n*value)
+			if len(parts) == 5 {
+				setSessionLanguage(strings.ToLower(parts[4]))
+			}
+			a.AddLogMsg(fmt.Sprintf("Session started for %s: %dx %s. Awaiting game choice (%s)",
+				playerName, n, itemClass, gameChoiceList()))
 
 		case strings.HasSuffix(command, "endsession"):
 			e.Block()
@@ -304,40 +287,44 @@ func (a *App) onChatMessage(e *g.Intercept) {
 				a.AddLogMsg("No active session to end.")
 				return
 			}
-			endSession()
+			endSession("ended by dealer")
 			a.AddLogMsg("Session ended.")
 
-		case strings.HasSuffix(command, "reset"):
+		case strings.HasSuffix(command, "cashout"):
 			e.Block()
-			resetDiceState()
-		case strings.HasSuffix(command, "roll") || strings.HasSuffix(command, "pkr"):
+			go a.cashOut()
 
+		case strings.HasSuffix(command, "risk"):
 			e.Block()
-			isPokerRolling = true
-			logRollResult := fmt.Sprintf("Poker Roll:\n")
-			a.AddLogMsg(logRollResult)
-			go a.rollPokerDice()
-		case strings.HasSuffix(command, "tri"):
+			go a.risk()
+
+		case strings.HasPrefix(command, "lang "):
+			// The dealer sets the language for the session player
 			e.Block()
-			isTriRolling = true
-			logRollResult := fmt.Sprint("Tri Roll:\n")
-			a.AddLogMsg(logRollResult)
-			go a.rollTriDice()
-		case strings.HasSuffix(command, "close"):
+			player := sessionPlayer()
+			if player == "" {
+				a.AddLogMsg("No active session to set the language for.")
+				return
+			}
+			a.setPlayerLanguage(player, strings.TrimPrefix(command, "lang "))
+
+		case strings.HasPrefix(command, "calibrate"):
 			e.Block()
-			go a.closeAllDice()
-		case strings.HasSuffix(command, "21"):
+			a.calibrate(strings.TrimSpace(strings.TrimPrefix(command, "calibrate")))
+
+		case strings.HasSuffix(command, "reset"):
 			e.Block()
-			isBJRolling = true
-			logRollResult := fmt.Sprintf("21 Roll:\n")
-			a.AddLogMsg(logRollResult)
-			go a.rollBjDice()
-		case strings.HasSuffix(command, "13"):
+			resetDiceState()
+			a.forgetCurrentBooth()
+		case gameForCommand(command) != nil:
 			e.Block()
-			is13Rolling = true
-			logRollResult := fmt.Sprintf("13 Roll:\n")
-			a.AddLogMsg(logRollResult)
-			go a.roll13Dice()
+			game := gameForCommand(command)
+			if err := a.startGame(game); err != nil {
+				a.AddLogMsg(game.Name() + " not started: " + err.Error())
+			}
+		case strings.HasSuffix(command, "close"):
+			e.Block()
+			a.closeDice()
 		case strings.HasPrefix(command, "@"):
 			e.Block()
 			extra := strings.TrimSpace(strings.TrimPrefix(command, "@"))
@@ -350,10 +337,10 @@ func (a *App) onChatMessage(e *g.Intercept) {
 			go a.ShowCommands()
 		case strings.HasSuffix(command, "chaton"):
 			e.Block()
-			ChatIsDisabled = false
+			chatDisabled.Store(false)
 		case strings.HasSuffix(command, "chatoff"):
 			e.Block()
-			ChatIsDisabled = true
+			chatDisabled.Store(true)
 		}
 	}
 }
@@ -361,31 +348,65 @@ func (a *App) onChatMessage(e *g.Intercept) {
 func (a *App) evalAt(msg string) {
 	mutex.Lock()
 	at := "@" + msg
-	ext.Send(out.SHOUT, at)
+	chatQueue.Push(routeChat(MessageResults, at, session.PlayerName, 0))
 	a.AddLogMsg(at)
 	mutex.Unlock()
 }
-func startSession(playerName, itemClass string, betCount int) {
+func startSession(playerName, betItems string, bet int) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
 	session = Session{
 		Active:             true,
+		ID:                 strconv.FormatInt(time.Now().UnixNano(), 36),
 		PlayerName:         playerName,
-		ItemClass:          itemClass,
-		BetCount:           betCount,
+		BetItems:           betItems,
+		Bet:                bet,
 		Balance:            0,
 		AwaitingGameChoice: true,
+		ChoiceSince:        time.Now(),
 		InGame:             false,
 		CanRisk:            false,
 		CanCashOut:         false,
 	}
+	recordSession(session, "start", "")
+	setSessionLanguage("")
+}
+
+// awaitGameChoice asks the player to pick the next game and restarts the
+// choice timeout. Callers must hold mutex.
+func (s *Session) awaitGameChoice() {
+	s.AwaitingGameChoice = true
+	s.ChoiceSince = time.Now()
+	s.ChoiceReminded = false
+	s.ChoiceExpired = false
+	s.HeldGame = ""
+}
+
+// retryChoiceTimeout lets an expired game choice time out again, once more
+// after the full timeout, when the refund it asked for didn't go through
+func (s *Session) retryChoiceTimeout() {
+	if s.AwaitingGameChoice && s.ChoiceExpired {
+		s.ChoiceExpired = false
+		s.ChoiceSince = time.Now()
+	}
+}
+
+// betWaiting reports whether a bet is waiting for a round: the player is
+// choosing a game, or a voided round is replayed. A won balance waits for
+// :risk or :cashout instead.
+func (s *Session) betWaiting() bool {
+	return s.AwaitingGameChoice || (s.Balance == 0 && s.Bet > 0 && !s.InGame)
 }
 
-func endSession() {
+func endSession(reason string) {
 	mutex.Lock()
 	defer mutex.Unlock()
+	if session.Active {
+		recordSession(session, "end", reason)
+	}
 	session = Session{}
+	setSessionLanguage("")
 }
 
 func sessionActive() bool {
@@ -393,71 +414,10 @@ func sessionActive() bool {
 	defer mutex.Unlock()
 	return session.Active
 }
-func countStripInstances(rawStr string, itemClass string) int {
-	// Count how many item instance IDs are in this STRIPINFO_2 line.
-	// In your payload, IDs look like: MjGl|MjGn|MjGHS ... HHduck
-	// So count pipes BEFORE the "HH<item>" marker.
-
-	marker := "HH" + itemClass
-	idx := strings.Index(rawStr, marker)
-	if idx == -1 {
-		// fallback: count all pipes if marker not found
-		p := strings.Count(rawStr, "|")
-		if p <= 0 {
-			return 1
-		}
-		return p + 1
-	}
-
-	prefix := rawStr[:idx]
-	p := strings.Count(prefix, "|")
-	if p <= 0 {
-		return 1
-	}
-	return p + 1
-}
-
-
-
-func (a *App) refreshInventoryAndWait(timeout time.Duration) {
-	// Don’t wipe invCounts here — it causes “0” windows.
-	// Only GETSTRIP "new" should clear the map.
-	invCollecting = true
-	invReady = false
-	lastStripInfoAt = time.Time{}
-
-	// Ask server for fresh inventory
-	a.ext.Send(out.GETSTRIP, []byte("AAnew"))
-
-	end := time.Now().Add(timeout)
-
-	// Wait until we’ve received at least one inventory packet
-	for time.Now().Before(end) {
-		time.Sleep(50 * time.Millisecond)
-
-		if invReady {
-			// Once ready, wait for packets to go quiet (inventory burst finished)
-			if !lastStripInfoAt.IsZero() && time.Since(lastStripInfoAt) > 500*time.Millisecond {
-				break
-			}
-		}
-	}
-
-	// IMPORTANT: do NOT set invCollecting=false here.
-	// We want to keep accepting STRIPINFO_2 updates continuously.
-}
-
 
-
-
-
-// Reset all saved dice states
+// Reset the booth dice of the current room
 func resetDiceState() {
-	mutex.Lock()
-	defer mutex.Unlock()
-	resultsWaitGroup.Wait() // Ensure all dice roll results are processed
-	diceList = []*Dice{}
-	isPokerRolling, isTriRolling, isBJRolling, is13Rolling, isHitting, is13Hitting, isClosing = false, false, false, false, false, false, false
+	table.Reset()
 }
 
 func (a *App) handleThrowDice(e *g.Intercept) {
@@ -466,6 +426,9 @@ func (a *App) handleThrowDice(e *g.Intercept) {
 	logrus.WithFields(logrus.Fields{"raw_data": rawData}).Debug("Raw packet data")
 
 	diceData := strings.Fields(rawData)
+	if len(diceData) < 1 {
+		return
+	}
 	diceIDStr := diceData[0]
 	diceID, err := strconv.Atoi(diceIDStr)
 	if err != nil {
@@ -473,25 +436,13 @@ func (a *App) handleThrowDice(e *g.Intercept) {
 		return
 	}
 
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	// Search for a dice with the given ID in the list
-	var existingDice *Dice
-	for _, dice := range diceList {
-		if dice != nil && dice.ID == diceID {
-			existingDice = dice
-			break
-		}
-	}
-
-	// If not found and the list has fewer than 5 dice, create and add a new one
-	if existingDice == nil && len(diceList) < 5 {
-		newDice := &Dice{ID: diceID, IsRolling: true, IsClosed: false}
-		diceList = append(diceList, newDice)
+	// Add the dice to the booth if it's new and the booth isn't full
+	added, count := table.Register(newBoothDice(diceID, false))
+	if added {
 		log.Printf("Dice %d added\n", diceID)
+		a.saveCurrentBooth()
 
-		if len(diceList) == 5 {
+		if count == boothSize {
 			message := "Dice setup sucessful! Run :roll to confirm"
 			a.AddLogMsg(message)
 		}
@@ -512,23 +463,10 @@ func (a *App) handleDiceOff(e *g.Intercept) {
 		return
 	}
 
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	// Search for a dice with the given ID in the list
-	var existingDice *Dice
-	for _, dice := range diceList {
-		if dice != nil && dice.ID == diceID {
-			existingDice = dice
-			break
-		}
-	}
-
-	// If not found and the list has fewer than 5 dice, create and add a new one
-	if existingDice == nil && len(diceList) < 5 {
-		newDice := &Dice{ID: diceID, IsRolling: false, IsClosed: true}
-		diceList = append(diceList, newDice)
+	// Add the dice to the booth if it's new and the booth isn't full
+	if added, _ := table.Register(newBoothDice(diceID, true)); added {
 		log.Printf("Dice %d added\n", diceID)
+		a.saveCurrentBooth()
 	}
 }
 
@@ -539,7 +477,7 @@ func (a *App) handleDiceResult(e *g.Intercept) {
 	logrus.WithFields(logrus.Fields{"raw_data": rawData}).Debug("Raw packet data")
 
 	diceData := strings.Fields(rawData)
-	if len(diceData) < 2 {
+	if len(diceData) < 1 {
 		return
 	}
 
@@ -550,117 +488,79 @@ func (a *App) handleDiceResult(e *g.Intercept) {
 		return
 	}
 
+	// The id alone means the dice is rolling, the value follows
+	if len(diceData) < 2 {
+		a.handleDiceRolling(diceID)
+		return
+	}
+
 	diceValueStr := diceData[1]
 	diceValue, err := strconv.Atoi(diceValueStr)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{"dice_value_str": diceValueStr, "error": err}).Warn("Failed to parse dice value")
 		return
 	}
-	adjustedDiceValue := diceValue - (diceID * 38)
-
-	mutex.Lock()
-	for i, dice := range diceList {
-		if dice.ID == diceID {
-			if dice.IsRolling && (isPokerRolling || isTriRolling || isBJRolling || is13Rolling || is13Hitting || isHitting) {
-				dice.IsRolling = false
-				resultsWaitGroup.Done()
-			}
-			diceList[i].Value = adjustedDiceValue
-			diceList[i].IsClosed = diceList[i].Value == 0
 
-			if isPokerRolling || isTriRolling || isBJRolling || is13Rolling || is13Hitting || isHitting {
-				log.Printf("Dice %d rolled: %d\n", diceID, adjustedDiceValue)
-				logRollResult := fmt.Sprintf("Dice %d rolled: %d\n", diceID, adjustedDiceValue)
-				a.AddLogMsg(logRollResult)
-			}
-			break
+	adjustedDiceValue, rolling, err := decodeDiceValue(diceID, diceValue, currentSettings().DiceMultiplier)
+	if err != nil {
+		// Other booths in the room are none of our business
+		if table.HasDice(diceID) {
+			a.reportUndecodable(diceID, diceValue, err)
 		}
+		return
 	}
-	mutex.Unlock()
-}
-// Splits packet raw bytes into human-ish tokens (Shockwave uses control separators like 0x02 and 0x7f)
-func splitTokens(raw []byte) []string {
-	s := string(raw)
-
-	// Replace common separators with spaces
-	replacer := strings.NewReplacer(
-		"\x02", " ",
-		"\x7f", " ",
-		"\x00", " ",
-		"\n", " ",
-		"\r", " ",
-		"\t", " ",
-	)
-	s = replacer.Replace(s)
-
-	parts := strings.Fields(s)
-	out := make([]string, 0, len(parts))
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
-		}
-		out = append(out, p)
+	if rolling {
+		a.handleDiceRolling(diceID)
+		return
 	}
-	return out
-}
-
 
-func countInstancesBeforeHH(rawStr string, itemClass string) int {
-	// Example: "...MjGl|MjGn|MjGo|MjGHS[2]i\\wBHHduck..."
-	// Count pipes BEFORE "HHduck" marker in THIS packet.
-	marker := "HH" + itemClass
-	idx := strings.Index(rawStr, marker)
-	if idx == -1 {
-		// fallback: pipes in entire string
-		p := strings.Count(rawStr, "|")
-		if p <= 0 {
-			return 1
-		}
-		return p + 1
+	switch update, previous := table.SetValue(diceID, adjustedDiceValue); update {
+	case valueExpected:
+		log.Printf("Dice %d rolled: %d\n", diceID, adjustedDiceValue)
+		logRollResult := fmt.Sprintf("Dice %d rolled: %d\n", diceID, adjustedDiceValue)
+		a.AddLogMsg(logRollResult)
+	case valueInterference:
+		go a.handleInterference(diceID, previous, adjustedDiceValue)
 	}
+}
 
-	prefix := rawStr[:idx]
-	p := strings.Count(prefix, "|")
-	if p <= 0 {
-		return 1
+// handleDiceRolling tells the table a dice started rolling, so a throw by
+// someone else is caught even if it lands on the face the dice showed
+func (a *App) handleDiceRolling(diceID int) {
+	if table.Rolling(diceID) {
+		log.Printf("Dice %d is rolling without the bot throwing it\n", diceID)
 	}
-	return p + 1
 }
 
-
-func extractLowerWord(s string) string {
-	// Find the last contiguous run of lowercase letters (e.g. "duck")
-	last := ""
-	cur := strings.Builder{}
-
-	for _, r := range s {
-		if r >= 'a' && r <= 'z' {
-			cur.WriteRune(r)
-		} else {
-			if cur.Len() >= 2 {
-				last = cur.String()
-			}
-			cur.Reset()
-		}
-	}
-	if cur.Len() >= 2 {
-		last = cur.String()
+func stripIDsBeforeHH(rawStr string, itemClass string) []string {
+	// STRIPINFO_2 lists the ids before "HH<item>": "...MjGl|MjGn|MjGHS[2]..."
+	// The last id runs up to the item type marker ("S" or "I" + [2]).
+	idx := strings.Index(rawStr, "HH"+itemClass)
+	if idx == -1 {
+		return nil
 	}
 
-	// Normalize common Shockwave prefixes:
-	// Trade tokens often contain "lduck" where the real class is "duck"
-	if strings.HasPrefix(last, "l") && len(last) >= 3 {
-		last = last[1:]
+	ids := strings.Split(rawStr[:idx], "|")
+	last := ids[len(ids)-1]
+	for _, marker := range []string{"S\x02", "I\x02"} {
+		if i := strings.Index(last, marker); i > 0 {
+			last = last[:i]
+			break
+		}
 	}
+	ids[len(ids)-1] = last
 
-	// Filter obvious junk that shows up in trade packets
-	switch last {
-	case "al", "ot", "other", "null", "trd":
-		return ""
+	// The first id follows the packet header and starts after the last byte
+	// that can't be part of an id
+	first := ids[0]
+	for i := len(first) - 1; i >= 0; i-- {
+		if first[i] < '@' || first[i] > 0x7f {
+			first = first[i+1:]
+			break
+		}
 	}
-
-	return last
+	ids[0] = first
+	return ids
 }
 
 func itemClassFromHH(raw string) string {
@@ -688,632 +588,292 @@ func itemClassFromHH(raw string) string {
 	return raw[i:j]
 }
 
-func extractItemClassAndCount(tokens []string) (itemClass string, count int) {
-	// Pull lowercase word candidates out of all tokens, then choose
-	// the most frequent candidate (that’s usually the traded item).
-	counts := map[string]int{}
-	for _, t := range tokens {
-		w := extractLowerWord(t)
-		if w == "" {
-			continue
-		}
-		counts[w]++
-	}
-
-	// pick best candidate: highest frequency, tie-breaker: longer word
-	best := ""
-	bestCount := 0
-	for w, c := range counts {
-		if c > bestCount || (c == bestCount && len(w) > len(best)) {
-			best = w
-			bestCount = c
-		}
-	}
-	return best, bestCount
-}
-
-
-func pickPartnerCandidate(tokens []string) string {
-	// Best-effort: pick the first token that looks like a username-ish thing.
-	// This may need tuning after you see logs.
-	for _, t := range tokens {
-		// Avoid obvious non-names
-		lt := strings.ToLower(t)
-		if lt == "trd" || lt == "useradmin" || lt == "flatctrl" || lt == "null" || lt == "other" {
-			continue
-		}
-		// allow letters, digits, underscore, hyphen
-		ok := true
-		for _, r := range t {
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
-				continue
-			}
-			ok = false
-			break
-		}
-		if ok && len(t) >= 2 && len(t) <= 24 {
-			return t
-		}
-	}
-	return ""
-}
-
-func (a *App) resetTradeCapture() {
-	tradeOpen = false
-	tradePartner = ""
-	tradeItemClass = ""
-	tradeBetCount = 0
-}
-
 // Called from InterceptAll (Step 2)
 func (a *App) handleTradeAndInv(e *g.Intercept) {
 	h := e.Packet.Header.Value
 
-	switch h {
-		
-	case 111: // TRADE_CONFIRM (Incoming) -> confirm screen shown
-	if !tradeOpen {
-		return
-	}
-	if !tradeAcceptedByBot {
-		return
-	}
-	if tradeItemClass == "" || tradeBetCount <= 0 {
-		return
-	}
-
-	needed := tradeBetCount * 2
-	a.refreshInventoryAndWait(4 * time.Second)
-
-	if !invReady {
-		a.AddLogMsg("AutoConfirm skipped: inventory not ready.")
-		return
-	}
-
-	have := invCounts[tradeItemClass]
-	a.AddLogMsg(fmt.Sprintf("AutoConfirm check: have %d %s, need %d", have, tradeItemClass, needed))
-
-if have >= needed {
-	a.ext.Send(g.Out.Id("TRADE_CONFIRM_ACCEPT"))
-	a.AddLogMsg("Trade: auto-confirmed")
-}
-return
-
-
-case 109: // TRADE_ACCEPT (Incoming) -> player clicked accept
-	if !tradeOpen {
+	// The payout trade for :cashout is handled separately
+	if a.handlePayoutTrade(e) {
 		return
 	}
 
-	if autoTradeAccept && !tradeAcceptedByBot && tradeCanAutoAccept {
-		a.ext.Send(out.TRADE_ACCEPT, []byte{})
-		tradeAcceptedByBot = true
-		a.AddLogMsg("Trade: auto-accepted (triggered by player accept)")
-	}
-	return
-
-
+	switch h {
 
-	case 72: // TRADE_ADDITEM (Outgoing) -> dealer is adding items
-		if tradeOpen {
-			dealerAddedInTrade++
+	case 111: // TRADE_CONFIRM (Incoming) -> confirm screen shown
+		mutex.Lock()
+		confirm := betTrade.Open && betTrade.AcceptedByBot && betTrade.Bet > 0 && !session.Active && !payout.Active
+		bet := betTrade.Bet
+		mutex.Unlock()
+		if !confirm {
+			return
 		}
-	return
-
-	// ---- Inventory capture ----
-	case 65: // GETSTRIP (Outgoing)
-		// When client requests new inventory, reset and start collecting
-		raw := string(e.Packet.Data)
-		if strings.Contains(raw, "new") {
-	// Only clear if we’re not already in a fresh cycle
-	invCounts = map[string]int{}
-	invReady = false
-	invCollecting = true
-	a.AddLogMsg("Inventory: collecting (GETSTRIP new)")
-}
 
-		return
+		needed := winPayout(bet)
+		if !handReady() {
+			a.AddLogMsg("AutoConfirm skipped: inventory not ready.")
+			return
+		}
 
-	case 140: // STRIPINFO_2 (Incoming)
-	rawStr := string(e.Packet.Data)
+		payable := canCoverBet(needed)
+		a.AddLogMsg(fmt.Sprintf("AutoConfirm check: can pay %d credits: %t", needed, payable))
 
-	item := itemClassFromHH(rawStr)
-	if item == "" {
+		if payable {
+			a.ext.Send(g.Out.Id("TRADE_CONFIRM_ACCEPT"))
+			a.AddLogMsg("Trade: auto-confirmed")
+		}
 		return
-	}
-
-	// This packet contains a full list for that item, so set it (don’t +=)
-	invCounts[item] = countInstancesBeforeHH(rawStr, item)
-
-	invReady = true
-	lastStripInfoAt = time.Now()
-	return
 
+	case 109: // TRADE_ACCEPT (Incoming) -> player clicked accept
+		mutex.Lock()
+		accept := betTrade.Open && autoTradeAccept && !betTrade.AcceptedByBot && betTrade.CanAutoAccept &&
+			!session.Active && !payout.Active
+		if accept {
+			betTrade.AcceptedByBot = true
+		}
+		mutex.Unlock()
 
-	case 98: // STRIPINFO (Incoming)
-	rawStr := string(e.Packet.Data)
-
-	item := itemClassFromHH(rawStr)
-	if item == "" {
+		if accept {
+			a.ext.Send(out.TRADE_ACCEPT, []byte{})
+			a.AddLogMsg("Trade: auto-accepted (triggered by player accept)")
+			a.emitLedgerUpdate()
+		}
 		return
-	}
-
-	invCounts[item] = countInstancesBeforeHH(rawStr, item)
-
-	invReady = true
-	lastStripInfoAt = time.Now()
-	return
-
-
-
 
 	case 108: // TRADE_ITEMS (Incoming)
-	if !tradeOpen {
-		return
-	}
-
-	tokens := splitTokens(e.Packet.Data)
-
-	item, total := extractItemClassAndCount(tokens)
-	if item == "" || total <= 0 {
-		return
-	}
-
-	// Subtract what the dealer added in this trade
-	bet := total - dealerAddedInTrade
-	if bet < 0 {
-		bet = 0
-	}
-
-	tradeItemClass = item
-	tradeBetCount = bet
-
-	a.AddLogMsg(fmt.Sprintf("Trade: items seen => %dx %s (total=%d dealerAdded=%d)",
-		tradeBetCount, tradeItemClass, total, dealerAddedInTrade))
+		mutex.Lock()
+		open := betTrade.Open
+		opened := betTrade.Partner
+		mutex.Unlock()
+		if !open {
+			return
+		}
 
-	// Auto-accept only if we can cover payout (never accept if we can't pay)
-	needed := tradeBetCount * 2
-a.refreshInventoryAndWait(2 * time.Second)
-have := invCounts[tradeItemClass]
-a.AddLogMsg(fmt.Sprintf("DEBUG INVENTORY: %s = %d", tradeItemClass, have))
-a.AddLogMsg(fmt.Sprintf("AutoAccept readiness: have %d %s, need %d", have, tradeItemClass, needed))
+		own, partner, err := decodeTradeItems(e.Packet.Data)
+		if err != nil {
+			a.AddLogMsg("Trade: couldn't read items: " + err.Error())
+			return
+		}
+		ownIDs := make([]int, 0, len(own.Items))
+		for _, item := range own.Items {
+			ownIDs = append(ownIDs, item.StripID)
+		}
+		verified := a.verifyTradePartner(partner.UserName, opened)
+		total, counts, unknown := valueItems(partner.Items)
+		valued := verified && len(unknown) == 0
 
-tradeCanAutoAccept = (have >= needed && tradeBetCount > 0 && tradeItemClass != "")
+		mutex.Lock()
+		betTrade.DealerAdded = len(own.Items)
+		betTrade.OwnIDs = ownIDs
+		betTrade.CanAutoAccept = false
+		betTrade.BetItems, betTrade.Bet, betTrade.BetCounts = "", 0, nil
+		if valued {
+			betTrade.BetItems = describeItems(counts)
+			betTrade.Bet = total
+			betTrade.BetCounts = counts
+		}
+		betItems := betTrade.BetItems
+		mutex.Unlock()
 
-return
+		if !verified {
+			return
+		}
+		if len(unknown) > 0 {
+			// Nothing to accept until every item has a value
+			a.AddLogMsg(fmt.Sprintf("Trade: %s's offer not accepted: no value for %s", partner.UserName, strings.Join(unknown, ", ")))
+			return
+		}
 
+		a.AddLogMsg(fmt.Sprintf("Trade: %s offers %s worth %d credits (dealer offers %d)",
+			partner.UserName, betItems, total, len(own.Items)))
 
+		// One bet at a time, a bet taken now would be ignored at completion
+		if sessionActive() {
+			a.AddLogMsg(fmt.Sprintf("Trade: %s's offer not accepted: a session is still running", partner.UserName))
+			return
+		}
+		if payoutActive() {
+			a.AddLogMsg(fmt.Sprintf("Trade: %s's offer not accepted: a payout is in progress", partner.UserName))
+			return
+		}
 
+		// Auto-accept only if we can cover payout (never accept if we can't pay)
+		needed := winPayout(total)
+		if !handReady() {
+			a.AddLogMsg("AutoAccept skipped: inventory not ready.")
+			a.refreshInventory("a bet was offered before the hand was known")
+			return
+		}
+		payable := canCoverBet(needed)
+		a.AddLogMsg(fmt.Sprintf("AutoAccept readiness: can pay %d credits: %t", needed, payable))
 
+		mutex.Lock()
+		betTrade.CanAutoAccept = payable && total > 0
+		mutex.Unlock()
+		return
 
 	case 104: // TRADE_OPEN (Incoming)
-		tradeCanAutoAccept = false
-		tradeNeeded = 0
-		dealerAddedInTrade = 0
-		tradeOpen = true
-		tradeAcceptedByBot = false
-		tradeItemClass = ""
-		tradeBetCount = 0
-		tradePartner = pickPartnerCandidate(splitTokens(e.Packet.Data))
-		a.AddLogMsg("Trade: opened")
-	return
+		if !handReady() {
+			a.refreshInventory("a trade was opened before the hand was known")
+		}
+		name := ""
+		index, err := readTradeOpen(e.Packet.Data)
+		if err == nil {
+			if found, ok := lookupRoomName(index); ok {
+				name = found
+			}
+		}
 
+		mutex.Lock()
+		betTrade = tradeCapture{Open: true, Partner: name}
+		mutex.Unlock()
 
+		switch {
+		case err != nil:
+			a.AddLogMsg("Trade: opened, couldn't read the partner: " + err.Error())
+		case name == "":
+			a.AddLogMsg(fmt.Sprintf("Trade: opened by room user %d who isn't in the user list", index))
+		default:
+			a.AddLogMsg("Trade: opened with " + name)
+		}
+		return
 
 	case 112: // TRADE_COMPLETED (Incoming)
-		if !tradeOpen {
+		// End trade capture state
+		mutex.Lock()
+		completed := betTrade
+		betTrade = tradeCapture{}
+		mutex.Unlock()
+		if !completed.Open {
 			return
 		}
 
-		// End trade capture state
-		tradeOpen = false
+		// The hand changed whatever happens to the bet
+		a.addTradeItems(completed.BetCounts)
+		if len(completed.OwnIDs) > 0 {
+			a.removeHandItems(completed.OwnIDs, "traded")
+		}
 
 		// Don't start if session already active
 		if sessionActive() {
-			a.AddLogMsg("Trade: completed but session already active (ignored)")
-			a.resetTradeCapture()
+			a.AddLogMsg(fmt.Sprintf("Trade: completed while a session is active, session not started. Return the %s by hand.",
+				completed.BetItems))
 			return
 		}
 
 		// Need item + count at minimum
-		if tradeItemClass == "" || tradeBetCount <= 0 {
-			a.AddLogMsg("Trade: completed but could not detect item/bet (ignored)")
-			a.resetTradeCapture()
+		if completed.Bet <= 0 {
+			a.AddLogMsg("Trade: completed but could not value the bet (ignored)")
 			return
 		}
 
-		// Partner name is best-effort; if empty, we still start but mark unknown
-		playerName := tradePartner
-		if strings.TrimSpace(playerName) == "" {
-			playerName = "UNKNOWN_PLAYER"
+		// A session needs a room user to pay out to
+		playerName := completed.Partner
+		if playerName == "" {
+			a.AddLogMsg(fmt.Sprintf("Trade: completed with an unverified partner, session not started. Return the %s by hand.",
+				completed.BetItems))
+			return
 		}
 
-needed := tradeBetCount * 2
-
-a.refreshInventoryAndWait(4 * time.Second)
-
-have := invCounts[tradeItemClass]
-a.AddLogMsg(fmt.Sprintf("Payout check: have %d %s, need %d", have, tradeItemClass, needed))
-
-// If inventory never updated, don't trust have=0
-	if !invReady {
-    a.AddLogMsg("Payout check failed: inventory not ready yet (no STRIPINFO_2 received). Denying bet.")
-	a.logAndMaybeShout("Session denied", "Inventory not ready. Please retry trade.")
-	a.resetTradeCapture()
-	return
-}
-
-if have < needed {
-	a.AddLogMsg(fmt.Sprintf("Session denied: need %d %s to cover payout, have %d", needed, tradeItemClass, have))
-	a.logAndMaybeShout("Session denied", fmt.Sprintf("Can't cover payout for %s (%d needed).", tradeItemClass, needed))
-	a.resetTradeCapture()
-	return
-}
+		needed := winPayout(completed.Bet)
+		payable := canCoverBet(needed)
+		a.AddLogMsg(fmt.Sprintf("Payout check: can pay %d credits: %t", needed, payable))
+
+		// If inventory never updated, don't trust have=0
+		if !handReady() {
+			a.AddLogMsg("Payout check failed: inventory not ready yet (no STRIPINFO_2 received). Denying bet.")
+			a.logAndSay("Session denied", msgNotReady, msgVars{
+				"player": playerName,
+				"bet":    strconv.Itoa(completed.Bet),
+				"items":  completed.BetItems,
+			})
+			return
+		}
 
+		if !payable {
+			a.AddLogMsg(fmt.Sprintf("Session denied: hand items can't make the %d credits payout", needed))
+			a.logAndSay("Session denied", msgCantCover, msgVars{
+				"player": playerName,
+				"bet":    strconv.Itoa(completed.Bet),
+				"items":  completed.BetItems,
+				"needed": strconv.Itoa(needed),
+			})
+			return
+		}
 
 		// Start session
-		startSession(playerName, tradeItemClass, tradeBetCount)
-
-		a.AddLogMsg(fmt.Sprintf("Session started via trade: %s bet %dx %s", playerName, tradeBetCount, tradeItemClass))
-		a.logAndMaybeShout(
-			"Session started",
-			fmt.Sprintf("%s bet %d %s. Choose game: :pkr, :tri, :21, :13", playerName, tradeBetCount, tradeItemClass),
-		)
-
-		a.resetTradeCapture()
+		startSession(playerName, completed.BetItems, completed.Bet)
+
+		a.AddLogMsg(fmt.Sprintf("Session started via trade: %s bet %s (%d credits)", playerName, completed.BetItems, completed.Bet))
+		a.logAndSay("Session started", msgSessionStarted, msgVars{
+			"player":    playerName,
+			"bet":       strconv.Itoa(completed.Bet),
+			"items":     completed.BetItems,
+			"games":     gameChoiceList(),
+			"languages": languageList(),
+		})
 		return
 
 	case 110: // TRADE_CLOSE (Incoming)
 		// Trade aborted/closed, clear capture
-		dealerAddedInTrade = 0
-		if tradeOpen {
+		mutex.Lock()
+		wasOpen := betTrade.Open
+		betTrade = tradeCapture{}
+		mutex.Unlock()
+		if wasOpen {
 			a.AddLogMsg("Trade: closed")
 		}
-		a.resetTradeCapture()
-		tradeAcceptedByBot = false
+		a.emitLedgerUpdate()
 		return
 	}
 }
 
 // Close the dice and send the packets to the game server
-func (a *App) closeAllDice() {
-	mutex.Lock()
-	isClosing = true
-	mutex.Unlock()
-
-	for _, dice := range diceList {
-		dice.Close()
-
-		// random delay between 550 and 600ms
-		time.Sleep(rollDelay + time.Duration(rand.Intn(50))*time.Millisecond)
-	}
-	mutex.Lock()
-	isClosing = false
-	mutex.Unlock()
-}
-
-// Roll the poker dice by sending packets and waiting for results
-func (a *App) rollPokerDice() {
-	mutex.Lock()
-
-	if len(diceList) < 5 {
-		mutex.Unlock()
-		log.Println("Not enough dice to roll")
-		isPokerRolling = false
-		return
-	}
-
-	for _, dice := range diceList {
-		dice.IsRolling = false
-	}
-	resultsWaitGroup.Add(len(diceList))
-	mutex.Unlock()
-
-	for _, dice := range diceList {
-		dice.Roll()
-
-		// random delay between 550 and 600ms
-		time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	}
-
-	time.Sleep(1000 * time.Millisecond)
-	if !waitForResults(5 * time.Second) {
-		a.AddLogMsg("Poker roll timed out waiting for dice results")
-		isPokerRolling = false
+func (a *App) closeDice() {
+	if err := table.BeginClose(); err != nil {
+		log.Println(err)
 		return
 	}
-	a.evaluatePokerRound()
-}
-
-func waitForResults(timeout time.Duration) bool {
-	done := make(chan struct{})
 	go func() {
-		resultsWaitGroup.Wait()
-		close(done)
+		defer table.Finish()
+		table.CloseAll()
 	}()
-
-	select {
-	case <-done:
-		return true
-	case <-time.After(timeout):
-		mutex.Lock()
-		resultsWaitGroup = sync.WaitGroup{}
-		for _, dice := range diceList {
-			dice.IsRolling = false
-		}
-		mutex.Unlock()
-		return false
-	}
-}
-
-// Evaluate the poker hand and send the result to the chat
-func (a *App) rollTriDice() {
-	mutex.Lock()
-
-	if len(diceList) < 5 {
-		mutex.Unlock()
-		log.Println("Not enough dice to roll")
-		isTriRolling = false
-		return
-	}
-
-	resultsWaitGroup.Add(3)
-	mutex.Unlock()
-
-	for _, index := range []int{0, 2, 4} {
-		diceList[index].Roll()
-		time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	}
-
-	time.Sleep(1000 * time.Millisecond)
-	resultsWaitGroup.Wait()
-
-	a.evaluateTriHand()
-	isTriRolling = false
-}
-
-// Roll dice for blackjack-style game
-func (a *App) rollBjDice() {
-	go a.closeAllDice()
-	time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	mutex.Lock()
-
-	if len(diceList) < 5 {
-		mutex.Unlock()
-		log.Println("Not enough dice to roll")
-		isBJRolling = false
-		return
-	}
-
-	currentSum = 0 // Reset sum before starting
-	resultsWaitGroup.Add(3)
-	mutex.Unlock()
-
-	// Roll the first three dice in order
-	for _, index := range []int{0, 1, 2} {
-		diceList[index].Roll()
-		time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	}
-
-	time.Sleep(1000 * time.Millisecond)
-	resultsWaitGroup.Wait()
-
-	mutex.Lock()
-	for _, index := range []int{0, 1, 2} {
-		currentSum += diceList[index].Value
-	}
-	mutex.Unlock()
-
-	a.evaluateBlackjackHand()
-	isBJRolling = false
-}
-
-func (a *App) hitBjDice() {
-	mutex.Lock()
-
-	if len(diceList) < 5 {
-		mutex.Unlock()
-		log.Println("Not enough dice to roll")
-		isBJRolling = false
-		isHitting = false
-		return
-	}
-
-	resultsWaitGroup.Add(1)
-	mutex.Unlock()
-
-	for i := 3; i < 5; i++ { // Start from index 3 to roll the next available dice
-		if diceList[i].Value == 0 {
-			diceList[i].Roll()
-			time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-			resultsWaitGroup.Wait()
-
-			mutex.Lock()
-			currentSum += diceList[i].Value // Add value to current sum
-			mutex.Unlock()
-
-			// Re-evaluate the hand after hitting
-			a.evaluateBlackjackHand()
-
-			isBJRolling = false
-			isHitting = false
-			return
-		}
-	}
-
-	// If all dice have been rolled, re-roll the last one
-	oldValue := diceList[4].Value
-	// sleep random between 1000 and 1500ms
-	time.Sleep(time.Duration(rand.Intn(1000)+500) * time.Millisecond)
-	diceList[4].Roll()
-
-	time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	resultsWaitGroup.Wait()
-	newValue := diceList[4].Value
-
-	mutex.Lock()
-	currentSum = currentSum + newValue // Adjust current sum
-	mutex.Unlock()
-
-	// Log the value of the dice rolled
-	log.Printf("Hit: Re-rolled dice %d = %d (old value was %d)\n", diceList[4].ID, newValue, oldValue)
-
-	// Re-evaluate the hand with the updated sum
-	a.evaluateBlackjackHand()
-
-	isHitting = false
-	isBJRolling = false
-}
-
-// Roll dice for blackjack-style game
-func (a *App) roll13Dice() {
-	go a.closeAllDice()
-	time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	mutex.Lock()
-
-	if len(diceList) < 5 {
-		mutex.Unlock()
-		log.Println("Not enough dice to roll")
-		is13Rolling = false
-		return
-	}
-
-	currentSum = 0 // Reset sum before starting
-	resultsWaitGroup.Add(2)
-	mutex.Unlock()
-
-	// Roll the first three dice in order
-	for _, index := range []int{0, 1} {
-		diceList[index].Roll()
-		time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	}
-
-	time.Sleep(1000 * time.Millisecond)
-	resultsWaitGroup.Wait()
-
-	mutex.Lock()
-	for _, index := range []int{0, 1} {
-		currentSum += diceList[index].Value
-	}
-	mutex.Unlock()
-
-	a.evaluate13Hand()
-	is13Rolling = false
-}
-
-func (a *App) hit13Dice() {
-	mutex.Lock()
-
-	if len(diceList) < 5 {
-		mutex.Unlock()
-		log.Println("Not enough dice to roll")
-		is13Rolling = false
-		is13Hitting = false
-		return
-	}
-
-	resultsWaitGroup.Add(1)
-	mutex.Unlock()
-
-	for i := 2; i < 5; i++ { // Start from index 2 to roll the next available dice
-		if diceList[i].Value == 0 {
-			diceList[i].Roll()
-			time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-			resultsWaitGroup.Wait()
-
-			mutex.Lock()
-			currentSum += diceList[i].Value // Add value to current sum
-			mutex.Unlock()
-
-			// Re-evaluate the hand after hitting
-			a.evaluate13Hand()
-
-			is13Rolling = false
-			is13Hitting = false
-			return
-		}
-	}
-
-	// If all dice have been rolled, re-roll the last one
-	oldValue := diceList[4].Value
-	// sleep random between 1000 and 1500ms
-	time.Sleep(time.Duration(rand.Intn(1000)+500) * time.Millisecond)
-	diceList[4].Roll()
-
-	time.Sleep(rollDelay + time.Duration(rand.Intn(100))*time.Millisecond)
-	resultsWaitGroup.Wait()
-	newValue := diceList[4].Value
-
-	mutex.Lock()
-	currentSum = currentSum + newValue // Adjust current sum
-	mutex.Unlock()
-
-	// Log the value of the dice rolled
-	log.Printf("Hit: Re-rolled dice %d = %d (old value was %d)\n", diceList[4].ID, newValue, oldValue)
-
-	// Re-evaluate the hand with the updated sum
-	a.evaluate13Hand()
-
-	is13Hitting = false
-	is13Rolling = false
 }
 
 func verifyResult() {
-	// Convert the currentSum to a string
-	sumStr := strconv.Itoa(currentSum)
-	mutex.Lock()
-	ext.Send(out.SHOUT, sumStr)
-	mutex.Unlock()
+	// Repeat the last announced result
+	chatQueue.Push(routeChat(MessageResults, table.LastResult(), sessionPlayer(), 0))
 }
 
 func (a *App) ShowCommands() {
+	lang := currentSettings().Language
+	line := "------------------------------------\n"
+	help := func(usage string, key string) string {
+		return usage + "\n" + localText(lang, key) + "\n" + line
+	}
 	commandList :=
-		"Thanks for using my plugin!\nBelow is it's list of commands. \n" +
-			"------------------------------------\n" +
-			":reset \n" +
-			"Forgets dice list for when you\nchange booth.\n" +
-			"------------------------------------\n" +
-			":roll \n" +
-			"Rolls 5 dice and if chat is enabled \nsays the results in chat. \n" +
-			"------------------------------------\n" +
-			":close\n" +
-			"Closes any of your open dice. \n" +
-			"------------------------------------\n" +
-			":21 \n" +
-			"Auto rolls and if chat is enabled \nsays the sum in chat when > 15. \n" +
-			"------------------------------------\n" +
-			":13 \n" +
-			"Auto rolls and if chat is enabled \nsays the sum in chat when > 8. \n" +
-			"------------------------------------\n" +
-			":tri \n" +
-			"Auto rolls 3 dice in Tri Formation \nif chat is enabled says the \nresults in chat. \n" +
-			"------------------------------------\n" +
-			":verify \n" +
-			"Will say the previous result in\nchat. Use if you were muted and\ndont know the results of 21/13.\n" +
-			"------------------------------------\n" +
-			":chaton \n" +
-			"Enables chat announcement \nof game results. \n" +
-			"------------------------------------\n" +
-			":chatoff \n" +
-			"Disables chat announcement \nof game results. \n" +
-			"------------------------------------\n" +
-			":@ <amount> \n" +
-			"Stores @ amount in roll log \nwith the result and will announce \nit in chat. \n" +
-			"------------------------------------\n" +
-			":session <player> <item> <count>\n" +
-			"Manual test: starts a session.\n" +
-			"------------------------------------\n" +
-			":endsession\n" +
-			"Ends the current session.\n" +
-			"------------------------------------\n" +
-			":commands - This help screen :)"
+		localText(lang, "help_intro") + "\n" + line +
+			help(":reset ", "help_reset") +
+			gameCommandsHelp(lang) +
+			help(":close", "help_close") +
+			help(":void", "help_void") +
+			help(":resume", "help_resume") +
+			help(":calibrate <face>", "help_calibrate") +
+			help(":verify ", "help_verify") +
+			help(":chaton ", "help_chaton") +
+			help(":chatoff ", "help_chatoff") +
+			help(":@ <amount> ", "help_at") +
+			help(":session <player> <item> <count> [language]", "help_session") +
+			help(":lang <code>", "help_lang") +
+			help(":endsession", "help_endsession") +
+			help(":cashout", "help_cashout") +
+			help(":risk", "help_risk") +
+			":commands - " + localText(lang, "help_commands")
 
 	// IMPORTANT: Sleep must be a standalone statement, NOT inside the string concatenation.
-	time.Sleep(time.Duration(rand.Intn(250)+250) * time.Millisecond)
+	activeTiming().ChatTyping.Sleep()
 	ext.Send(in.SYSTEM_BROADCAST, commandList)
 }
 
-
 // QDave's Logging function for frontend
 func (a *App) AddLogMsg(msg string) {
 	a.logMu.Lock()
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	g "xabbo.b7c.io/goearth"
+)
+
+// Raw headers of the mute packets: 4069 when muted with the duration in
+// seconds, 3285 with the time left when trying to chat while muted.
+const (
+	headerMuted         = 4069
+	headerMuteRemaining = 3285
+)
+
+// MuteTracker keeps the one mute deadline. Every mute packet moves the
+// deadline, a single countdown goroutine watches it.
+type MuteTracker struct {
+	mu      sync.Mutex
+	until   time.Time
+	changed chan struct{}
+}
+
+var mute = &MuteTracker{changed: make(chan struct{}, 1)}
+
+// Set mutes for the given number of seconds from now
+func (m *MuteTracker) Set(seconds int) {
+	m.mu.Lock()
+	m.until = time.Now().Add(time.Duration(seconds) * time.Second)
+	m.mu.Unlock()
+
+	select {
+	case m.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Muted reports whether the dealer is muted right now
+func (m *MuteTracker) Muted() bool {
+	return m.Remaining() > 0
+}
+
+// Remaining returns the time left on the mute
+func (m *MuteTracker) Remaining() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if left := time.Until(m.until); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Mute detection logic (called within InterceptAll)
+func handleMutePacket(e *g.Intercept) {
+	switch e.Packet.Header.Value {
+	case headerMuted:
+		seconds := e.Packet.ReadInt()
+		log.Printf("You are muted for %d seconds.", seconds)
+		mute.Set(seconds)
+	case headerMuteRemaining:
+		// The server's count is the real one, ours drifts
+		seconds := e.Packet.ReadInt()
+		log.Printf("Mute still active, remaining time: %d seconds.", seconds)
+		mute.Set(seconds)
+	}
+}
+
+// runMuteTracker counts the mute down to the GUI and sends the queued chat
+// once it lifts
+func (a *App) runMuteTracker() {
+	for {
+		<-mute.changed
+		for {
+			left := mute.Remaining()
+			a.emitMuteUpdate(left)
+			if left == 0 {
+				break
+			}
+			tick := time.Second
+			if left < tick {
+				tick = left
+			}
+			select {
+			case <-mute.changed:
+			case <-time.After(tick):
+			}
+		}
+		a.handleMuteEnd()
+	}
+}
+
+func (a *App) handleMuteEnd() {
+	log.Println("Mute finished, sending queued messages...")
+	chatQueue.Wake()
+	a.startHeldGame()
+}
+
+func (a *App) emitMuteUpdate(left time.Duration) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "muteUpdate", int((left+time.Second-1)/time.Second))
+	}
+}
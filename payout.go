@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	g "xabbo.b7c.io/goearth"
+	"xabbo.b7c.io/goearth/shockwave/out"
+)
+
+// How long to wait for the payout trade window to open
+const payoutOpenTimeout = 10 * time.Second
+
+// payoutTrade tracks the outgoing trade that pays a session balance back to
+// the player. Only one payout runs at a time.
+type payoutTrade struct {
+	Active bool
+	Opened bool
+	Kind   string // "cashout" or "refund"
+	Player string
+	// Credits owed and the hand items by class that pay them
+	Credits int
+	Items   map[string]int
+	// Strip ids put in the trade window, taken out of the hand on completion
+	StripIDs []int
+	// The last TRADE_ITEMS showed exactly StripIDs on our side and nothing
+	// on the player's
+	Checked bool
+
+	// Set once all items are in the trade window
+	ItemsAdded bool
+	// Set once we accepted / confirmed our side
+	Accepted  bool
+	Confirmed bool
+}
+
+var payout payoutTrade
+
+func payoutActive() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return payout.Active
+}
+
+// cashOut opens a trade with the session player and pays out the balance.
+// The session is only ended after the trade completes.
+func (a *App) cashOut() {
+	mutex.Lock()
+	if !session.Active {
+		mutex.Unlock()
+		a.AddLogMsg("Cashout failed: no active session.")
+		return
+	}
+	if !session.CanCashOut || session.Balance <= 0 {
+		mutex.Unlock()
+		a.AddLogMsg("Cashout failed: session has no balance to cash out.")
+		return
+	}
+	credits := session.Balance
+	mutex.Unlock()
+
+	a.startPayout("cashout", credits)
+}
+
+// refundBet gives the bet that is still in play back to the player.
+func (a *App) refundBet() {
+	mutex.Lock()
+	if !session.Active || session.Bet <= 0 {
+		mutex.Unlock()
+		a.AddLogMsg("Refund failed: no bet to refund.")
+		return
+	}
+	credits := session.Bet
+	mutex.Unlock()
+
+	a.startPayout("refund", credits)
+}
+
+// startPayout opens a trade with the session player to hand over hand items
+// worth the credits, in whatever mix of classes adds up to them.
+func (a *App) startPayout(kind string, credits int) {
+	mutex.Lock()
+	if payout.Active {
+		mutex.Unlock()
+		a.AddLogMsg("Payout already in progress.")
+		return
+	}
+	player := session.PlayerName
+	mutex.Unlock()
+
+	if tradeIsOpen() {
+		a.payoutNotStarted(kind, "another trade is open. Close it and retry.")
+		return
+	}
+
+	index, ok := lookupRoomIndex(player)
+	if !ok {
+		a.payoutNotStarted(kind, player+" is not in the room.")
+		return
+	}
+
+	if !handReady() {
+		a.AddLogMsg(fmt.Sprintf("%s: hand not read yet, retrying after a refresh", payoutTitle(kind)))
+		a.afterRefresh("a payout was asked for before the hand was known", func() {
+			if !handReady() {
+				a.payoutNotStarted(kind, "the hand couldn't be read.")
+				return
+			}
+			a.startPayout(kind, credits)
+		})
+		return
+	}
+
+	items, ok := planPayout(credits, inventoryStock())
+	if !ok {
+		a.payoutNotStarted(kind, fmt.Sprintf("hand items can't make %d credits.", credits))
+		return
+	}
+
+	mutex.Lock()
+	payout = payoutTrade{
+		Active:  true,
+		Kind:    kind,
+		Player:  player,
+		Credits: credits,
+		Items:   items,
+	}
+	mutex.Unlock()
+
+	a.AddLogMsg(fmt.Sprintf("%s: opening trade with %s for %d credits (%s)", payoutTitle(kind), player, credits, describeItems(items)))
+	a.ext.Send(out.TRADE_OPEN, []byte(fmt.Sprintf("%d", index)))
+	a.emitLedgerUpdate()
+
+	time.AfterFunc(payoutOpenTimeout, func() {
+		mutex.Lock()
+		stale := payout.Active && !payout.Opened
+		mutex.Unlock()
+		if stale {
+			a.failPayout("trade window never opened")
+		}
+	})
+}
+
+// payoutTitle is the log prefix for a payout kind
+func payoutTitle(kind string) string {
+	if kind == "refund" {
+		return "Refund"
+	}
+	return "Cashout"
+}
+
+// payoutNotStarted logs a payout that couldn't be opened. The session stays
+// open like after failPayout.
+func (a *App) payoutNotStarted(kind string, reason string) {
+	mutex.Lock()
+	session.retryChoiceTimeout()
+	mutex.Unlock()
+	a.AddLogMsg(fmt.Sprintf("%s failed: %s", payoutTitle(kind), reason))
+}
+
+// failPayout clears the payout trade and leaves the session open.
+func (a *App) failPayout(reason string) {
+	mutex.Lock()
+	if !payout.Active {
+		mutex.Unlock()
+		return
+	}
+	player := payout.Player
+	kind := payout.Kind
+	payout = payoutTrade{}
+	session.retryChoiceTimeout()
+	recordSession(session, kind+"_failed", reason)
+	mutex.Unlock()
+
+	a.AddLogMsg(fmt.Sprintf("%s failed for %s: %s. Session kept open.", payoutTitle(kind), player, reason))
+}
+
+// addPayoutItems puts the balance into the open trade window
+func (a *App) addPayoutItems() {
+	mutex.Lock()
+	kind := payout.Kind
+	items := payout.Items
+	mutex.Unlock()
+
+	var ids []int
+	for class, count := range items {
+		have := stripIDsFor(class)
+		if len(have) < count {
+			a.failPayout(fmt.Sprintf("only %d %s left in hand", len(have), class))
+			a.ext.Send(out.TRADE_CLOSE)
+			a.refreshInventory("a payout found fewer " + class + " than expected")
+			return
+		}
+		ids = append(ids, have[:count]...)
+	}
+
+	mutex.Lock()
+	payout.StripIDs = ids
+	mutex.Unlock()
+
+	for _, id := range ids {
+		if !payoutActive() {
+			return
+		}
+		a.ext.Send(out.TRADE_ADDITEM, []byte(wireStripID(id)))
+		time.Sleep(time.Duration(rand.Intn(150)+150) * time.Millisecond)
+	}
+
+	mutex.Lock()
+	payout.ItemsAdded = true
+	mutex.Unlock()
+	a.AddLogMsg(fmt.Sprintf("%s: added %s, waiting for player to accept", payoutTitle(kind), describeItems(items)))
+}
+
+// handlePayoutTrade handles trade packets while a payout is running.
+// It returns false when the packet is not part of the payout.
+func (a *App) handlePayoutTrade(e *g.Intercept) bool {
+	if !payoutActive() {
+		return false
+	}
+
+	mutex.Lock()
+	opened := payout.Opened
+	player := payout.Player
+	mutex.Unlock()
+
+	switch e.Packet.Header.Value {
+	case 104: // TRADE_OPEN (Incoming)
+		if opened {
+			return true
+		}
+		name := ""
+		if index, err := readTradeOpen(e.Packet.Data); err == nil {
+			name, _ = lookupRoomName(index)
+		}
+		if !strings.EqualFold(name, player) {
+			// Someone else's trade, the payout keeps waiting for the player
+			a.AddLogMsg(fmt.Sprintf("Payout: closing a trade opened by %q, waiting for %s", name, player))
+			a.ext.Send(out.TRADE_CLOSE)
+			return true
+		}
+		mutex.Lock()
+		payout.Opened = true
+		mutex.Unlock()
+		a.AddLogMsg("Payout: trade opened")
+		go a.addPayoutItems()
+
+	case 108: // TRADE_ITEMS (Incoming)
+		if !opened {
+			return true
+		}
+		own, partner, err := decodeTradeItems(e.Packet.Data)
+		if err != nil {
+			a.AddLogMsg("Payout: couldn't read trade items: " + err.Error())
+		}
+		ownIDs := make([]int, 0, len(own.Items))
+		for _, item := range own.Items {
+			ownIDs = append(ownIDs, item.StripID)
+		}
+
+		mutex.Lock()
+		payout.Checked = err == nil && len(partner.Items) == 0 && sameStripIDs(ownIDs, payout.StripIDs)
+		if !payout.Checked {
+			// The trade changed after we accepted, accept again once it's right
+			payout.Accepted = false
+		}
+		mutex.Unlock()
+		if err == nil && len(partner.Items) > 0 {
+			a.AddLogMsg(fmt.Sprintf("Payout: %s put items in the trade, not accepting", partner.UserName))
+		}
+
+	case 109: // TRADE_ACCEPT (Incoming)
+		mutex.Lock()
+		ready := payout.ItemsAdded && payout.Checked && !payout.Accepted
+		if ready {
+			payout.Accepted = true
+		}
+		mutex.Unlock()
+		if ready {
+			a.ext.Send(out.TRADE_ACCEPT, []byte{})
+			a.AddLogMsg("Payout: player accepted, accepting")
+		}
+
+	case 111: // TRADE_CONFIRM (Incoming)
+		mutex.Lock()
+		ready := payout.Accepted && payout.Checked && !payout.Confirmed
+		if ready {
+			payout.Confirmed = true
+		}
+		mutex.Unlock()
+		if ready {
+			a.ext.Send(g.Out.Id("TRADE_CONFIRM_ACCEPT"))
+			a.AddLogMsg("Payout: confirmed")
+		}
+
+	case 112: // TRADE_COMPLETED (Incoming)
+		if !opened {
+			return true
+		}
+		mutex.Lock()
+		kind := payout.Kind
+		credits := payout.Credits
+		paid := describeItems(payout.Items)
+		given := payout.StripIDs
+		payout = payoutTrade{}
+		mutex.Unlock()
+		a.removeHandItems(given, "paid out")
+
+		// Said before the session ends, while it's still in the player's
+		// language
+		vars := msgVars{
+			"player": player,
+			"count":  strconv.Itoa(credits),
+			"items":  paid,
+		}
+		if kind == "refund" {
+			a.logAndSay("Refund", msgRefundDone, vars)
+			endSession(fmt.Sprintf("refunded %d credits (%s)", credits, paid))
+			a.AddLogMsg(fmt.Sprintf("Refund complete: returned %s to %s. Session ended.", paid, player))
+			return true
+		}
+
+		a.logAndSay("Cashout", msgCashoutDone, vars)
+		endSession(fmt.Sprintf("cashed out %d credits (%s)", credits, paid))
+		a.AddLogMsg(fmt.Sprintf("Cashout complete: paid %s to %s. Session ended.", paid, player))
+
+	case 110: // TRADE_CLOSE (Incoming)
+		// A trade closed before ours opened was someone else's, the open
+		// timeout covers ours never opening
+		if opened {
+			a.failPayout("trade was closed before completing")
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// sameStripIDs reports whether two lists hold the same strip ids in any order
+func sameStripIDs(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]int(nil), a...)
+	b = append([]int(nil), b...)
+	sort.Ints(a)
+	sort.Ints(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
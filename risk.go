@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// risk puts the session balance back in play as the next bet. A win pays
+// it out again, a loss ends the session.
+func (a *App) risk() {
+	limits := currentSettings()
+
+	mutex.Lock()
+	if !session.Active {
+		mutex.Unlock()
+		a.AddLogMsg("Risk failed: no active session.")
+		return
+	}
+	if !session.CanRisk || session.Balance <= 0 {
+		mutex.Unlock()
+		a.AddLogMsg("Risk failed: session has no balance to risk.")
+		return
+	}
+	if payout.Active {
+		mutex.Unlock()
+		a.AddLogMsg("Risk failed: cashout in progress.")
+		return
+	}
+
+	player := session.PlayerName
+	id := session.ID
+	stake := session.Balance
+	needed := winPayout(stake)
+
+	var denied string
+	switch {
+	case limits.MaxRisks > 0 && session.RiskCount >= limits.MaxRisks:
+		denied = fmt.Sprintf("max %d risks in a row reached", limits.MaxRisks)
+	case limits.MaxBalance > 0 && needed > limits.MaxBalance:
+		denied = fmt.Sprintf("%d credits is over the max balance of %d", needed, limits.MaxBalance)
+	}
+	mutex.Unlock()
+
+	// The ledger takes mutex itself
+	if denied == "" && !canCoverSession(needed) {
+		denied = fmt.Sprintf("hand items can't make %d credits", needed)
+	}
+
+	mutex.Lock()
+	if !session.Active || session.ID != id || session.Balance != stake || !session.CanRisk {
+		mutex.Unlock()
+		a.AddLogMsg("Risk failed: the session changed.")
+		return
+	}
+	if denied != "" {
+		recordSession(session, "risk_denied", denied)
+		mutex.Unlock()
+		a.AddLogMsg("Risk denied: " + denied)
+		a.logAndSay("Risk denied", msgRiskDenied, msgVars{
+			"player":  player,
+			"balance": strconv.Itoa(stake),
+			"reason":  denied,
+		})
+		return
+	}
+
+	session.RiskCount++
+	session.Bet = stake
+	session.Balance = 0
+	session.CanRisk = false
+	session.CanCashOut = false
+	session.awaitGameChoice()
+	riskCount := session.RiskCount
+	recordSession(session, "risk", fmt.Sprintf("risk %d: %d credits in play", riskCount, stake))
+	mutex.Unlock()
+
+	a.AddLogMsg(fmt.Sprintf("Risk %d: %s puts %d credits in play", riskCount, player, stake))
+	a.logAndSay("Session risk", msgRisk, msgVars{
+		"player": player,
+		"bet":    strconv.Itoa(stake),
+		"needed": strconv.Itoa(needed),
+		"games":  gameChoiceList(),
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	g "xabbo.b7c.io/goearth"
+)
+
+// Room user indexes by lower-case name and names by index, built from USERS
+// and LOGOUT packets and cleared on room entry. The trade and chat packets
+// address users by room index.
+var (
+	roomUserIndex = map[string]int{}
+	roomUserNames = map[int]string{}
+)
+
+// Room the dealer is in, from ROOM_READY. 0 until a room is entered.
+var currentRoomID int
+
+// Handle the USERS packet (users entering / already in the room)
+func (a *App) handleUsers(e *g.Intercept) {
+	r := newWireReader(e.Packet.Data)
+	n, err := r.readInt()
+	if err != nil {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	for i := 0; i < n; i++ {
+		index, name, ok := readRoomUser(r)
+		if !ok {
+			return
+		}
+		roomUserIndex[strings.ToLower(name)] = index
+		roomUserNames[index] = name
+	}
+}
+
+// Handle the LOGOUT packet (a user left the room)
+func (a *App) handleUserLeave(e *g.Intercept) {
+	idStr, err := newWireReader(e.Packet.Data).readString()
+	if err != nil {
+		return
+	}
+	index, err := strconv.Atoi(idStr)
+	if err != nil {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if name, ok := roomUserNames[index]; ok {
+		delete(roomUserIndex, strings.ToLower(name))
+		delete(roomUserNames, index)
+	}
+}
+
+// resetRoomUsers forgets the users of the previous room. Callers must hold
+// mutex.
+func resetRoomUsers() {
+	roomUserIndex = map[string]int{}
+	roomUserNames = map[int]string{}
+}
+
+// readRoomUser reads a single entity entry of the USERS packet and returns
+// its room index and name.
+func readRoomUser(r *wireReader) (index int, name string, ok bool) {
+	var err error
+	if index, err = r.readInt(); err != nil {
+		return 0, "", false
+	}
+	if name, err = r.readString(); err != nil {
+		return 0, "", false
+	}
+	// figure, gender, custom
+	for i := 0; i < 3; i++ {
+		if _, err = r.readString(); err != nil {
+			return 0, "", false
+		}
+	}
+	// x, y
+	for i := 0; i < 2; i++ {
+		if _, err = r.readInt(); err != nil {
+			return 0, "", false
+		}
+	}
+	// z, pool figure, badge
+	for i := 0; i < 3; i++ {
+		if _, err = r.readString(); err != nil {
+			return 0, "", false
+		}
+	}
+	// entity type
+	if _, err = r.readInt(); err != nil {
+		return 0, "", false
+	}
+	return index, name, true
+}
+
+func lookupRoomIndex(name string) (int, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	index, ok := roomUserIndex[strings.ToLower(name)]
+	return index, ok
+}
+
+func lookupRoomName(index int) (string, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	name, ok := roomUserNames[index]
+	return name, ok
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strconv"
+)
+
+// Result of a player vs dealer round, from the player's side
+type roundOutcome int
+
+const (
+	outcomeLose roundOutcome = iota
+	outcomeWin
+	outcomePush
+)
+
+// beginSessionRound marks the session as in game. It returns false when no
+// session bet is waiting for a round, in which case the game is played as a
+// plain roll.
+func beginSessionRound(game string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !session.Active || !session.betWaiting() {
+		return false
+	}
+	session.AwaitingGameChoice = false
+	session.InGame = true
+	recordSession(session, "round", game)
+	return true
+}
+
+// sessionHoldsBalance reports whether the session has a won balance waiting
+// for :risk or :cashout, when no game may be started
+func sessionHoldsBalance() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return session.Active && session.Balance > 0 && !session.AwaitingGameChoice
+}
+
+// sessionPlayer is the name of the player in session, empty without one
+func sessionPlayer() string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !session.Active {
+		return ""
+	}
+	return session.PlayerName
+}
+
+// settleRound feeds the outcome of a round into the session: a win pays the
+// bet times multiplier into the balance, a push keeps the bet in play and a
+// loss ends it.
+func (a *App) settleRound(game string, outcome roundOutcome, multiplier float64, detail string) {
+	mutex.Lock()
+	if !session.Active {
+		mutex.Unlock()
+		return
+	}
+	session.InGame = false
+	session.Replays = 0
+	playerName := session.PlayerName
+	bet := session.Bet
+
+	switch outcome {
+	case outcomeWin:
+		newBal := int(float64(bet) * multiplier)
+		session.Balance = newBal
+		session.CanRisk = true
+		session.CanCashOut = true
+		recordSession(session, "win", game+": "+detail)
+		mutex.Unlock()
+
+		// Announce bankroll after win
+		a.logAndSay("Session update", msgSessionWin, msgVars{
+			"game":    game,
+			"player":  playerName,
+			"balance": strconv.Itoa(newBal),
+		})
+
+	case outcomePush:
+		session.awaitGameChoice()
+		recordSession(session, "push", game+": "+detail)
+		mutex.Unlock()
+
+		a.logAndSay("Session update", msgSessionPush, msgVars{
+			"game":   game,
+			"player": playerName,
+			"bet":    strconv.Itoa(bet),
+			"games":  gameChoiceList(),
+		})
+
+	default:
+		mutex.Unlock()
+		a.AddLogMsg("Session ended: player lost the round.")
+		endSession(game + ": " + detail)
+	}
+}
+
+// voidRound drops a round that couldn't be played to the end, like when a
+// dice never reported a value or the dealer used :void. Nobody wins. With
+// the replay policy the round is played again once, otherwise (or when the
+// replay is void too) the bet is refunded by trade. It returns true when the
+// round should be replayed.
+func (a *App) voidRound(game string, cause error) bool {
+	a.AddLogMsg(game + " round void: " + cause.Error())
+
+	mutex.Lock()
+	if !session.Active {
+		mutex.Unlock()
+		a.logAndSay(game+" void", msgRollVoid, msgVars{
+			"game":   game,
+			"reason": cause.Error(),
+		})
+		return false
+	}
+	session.InGame = false
+	replay := currentSettings().VoidPolicy == "replay" && session.Replays < 1
+	if replay {
+		session.Replays++
+	}
+	recordSession(session, "void", game+": "+cause.Error())
+	playerName := session.PlayerName
+	bet := session.Bet
+	mutex.Unlock()
+
+	vars := msgVars{
+		"game":   game,
+		"reason": cause.Error(),
+		"player": playerName,
+		"bet":    strconv.Itoa(bet),
+	}
+	if replay {
+		a.logAndSay("Session update", msgVoidReplay, vars)
+		return true
+	}
+
+	a.logAndSay("Session update", msgVoidRefund, vars)
+	go a.refundBet()
+	return false
+}
+
+// awaitChoiceAfterVoid lets the player pick a game again when a replay
+// couldn't be started
+func (a *App) awaitChoiceAfterVoid() {
+	mutex.Lock()
+	if !session.Active {
+		mutex.Unlock()
+		return
+	}
+	session.awaitGameChoice()
+	recordSession(session, "choose", "replay not started")
+	playerName := session.PlayerName
+	mutex.Unlock()
+
+	a.logAndSay("Session update", msgChooseGame, msgVars{
+		"player": playerName,
+		"games":  gameChoiceList(),
+	})
+}
+
+// resumeRound is the :resume command
+func (a *App) resumeRound() {
+	if err := table.Resume(); err != nil {
+		a.AddLogMsg("Resume failed: " + err.Error())
+		return
+	}
+	a.AddLogMsg("Resuming " + table.Game() + "...")
+}
+
+// voidCurrentRound is the :void command
+func (a *App) voidCurrentRound() {
+	if err := table.Void("voided by dealer"); err != nil {
+		a.AddLogMsg("Void failed: " + err.Error())
+		return
+	}
+	a.AddLogMsg("Voiding " + table.Game() + "...")
+}
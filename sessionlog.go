@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+)
+
+// SessionEvent is one line of the session history file. Every bet, round,
+// risk and payout is appended so a disputed session can be rebuilt.
+type SessionEvent struct {
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id"`
+	Event     string    `json:"event"`
+	Player    string    `json:"player"`
+	BetItems  string    `json:"items"`
+	Bet       int       `json:"bet"`
+	Balance   int       `json:"balance"`
+	RiskCount int       `json:"risks"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+func getSessionHistoryFilePath() string {
+	return configFilePath("session_history.jsonl")
+}
+
+// recordSession appends an event for s to the session history file.
+// Callers must hold mutex (or pass a copy of the session).
+func recordSession(s Session, event string, detail string) {
+	entry := SessionEvent{
+		Time:      time.Now(),
+		SessionID: s.ID,
+		Event:     event,
+		Player:    s.PlayerName,
+		BetItems:  s.BetItems,
+		Bet:       s.Bet,
+		Balance:   s.Balance,
+		RiskCount: s.RiskCount,
+		Detail:    detail,
+	}
+
+	file, err := os.OpenFile(getSessionHistoryFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Printf("Error opening session history: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(entry); err != nil {
+		log.Printf("Error writing session history: %v", err)
+	}
+	// Every session change moves what the dealer owes
+	if app != nil {
+		app.emitLedgerUpdate()
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// BotSettings holds the dealer's session limits, editable from the GUI
+type BotSettings struct {
+	// Maximum number of :risk in a row for one session
+	MaxRisks int `json:"max_risks"`
+	// Maximum balance in credits a session may reach through :risk
+	MaxBalance int `json:"max_balance"`
+
+	// Seconds the player has to choose a game, 0 disables the timeout
+	ChoiceTimeout int `json:"choice_timeout"`
+	// What to do when the player never chooses: "refund" or "end"
+	ChoiceTimeoutAction string `json:"choice_timeout_action"`
+
+	// Times a dice that didn't report a value is thrown again before the
+	// round is void
+	DiceRetries int `json:"dice_retries"`
+
+	// What to do with a void round: "replay" it once or "refund" the bet
+	VoidPolicy string `json:"void_policy"`
+
+	// What to do when someone else changes a booth dice during a game:
+	// "flag" only logs it, "pause" holds the game, "void" voids it
+	InterferencePolicy string `json:"interference_policy"`
+
+	// DICE_VALUE encoding: raw value = dice id * multiplier + face
+	DiceMultiplier int `json:"dice_multiplier"`
+
+	// Timing profile the dealer throws and talks with: "fast", "natural"
+	// or "cautious"
+	TimingProfile string `json:"timing_profile"`
+
+	// Most chat messages sent per chat window (seconds), the rest wait
+	ChatLimit  int `json:"chat_limit"`
+	ChatWindow int `json:"chat_window"`
+
+	// Don't start games while muted, nobody would hear the results
+	PauseGamesWhileMuted bool `json:"pause_games_while_muted"`
+
+	// Shape of the booth the dice are sorted by: "row", "arc" or "pentagon"
+	BoothLayout string `json:"booth_layout"`
+
+	// Channel every message type is sent on, by type: "shout", "talk" or
+	// "whisper" to the session player
+	Channels map[string]string `json:"channels"`
+
+	// Language code chat and help are in unless the session player picks
+	// another with :lang, "en" or a lang_<code>.json pack
+	Language string `json:"language"`
+}
+
+var (
+	settings   = defaultSettings()
+	settingsMu sync.RWMutex
+)
+
+func defaultSettings() BotSettings {
+	return BotSettings{
+		MaxRisks:             3,
+		MaxBalance:           50,
+		ChoiceTimeout:        120,
+		ChoiceTimeoutAction:  "refund",
+		DiceRetries:          2,
+		VoidPolicy:           "replay",
+		InterferencePolicy:   "pause",
+		DiceMultiplier:       defaultDiceMultiplier,
+		TimingProfile:        TimingNatural,
+		ChatLimit:            4,
+		ChatWindow:           8,
+		PauseGamesWhileMuted: true,
+		BoothLayout:          LayoutRow,
+		Channels:             defaultChannels(),
+		Language:             defaultLanguage,
+	}
+}
+
+func getSettingsFilePath() string {
+	return configFilePath("bot_settings.json")
+}
+
+// currentSettings returns a copy of the active settings
+func currentSettings() BotSettings {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
+
+func (a *App) LoadSettings() *BotSettings {
+	loaded := defaultSettings()
+
+	file, err := os.Open(getSettingsFilePath())
+	if err == nil {
+		defer file.Close()
+		if err := json.NewDecoder(file).Decode(&loaded); err != nil {
+			a.AddLogMsg("Error decoding settings file: " + err.Error())
+			loaded = defaultSettings()
+		}
+	}
+	if !validLayout(loaded.BoothLayout) {
+		loaded.BoothLayout = LayoutRow
+	}
+	if !validTimingProfile(loaded.TimingProfile) {
+		loaded.TimingProfile = TimingNatural
+	}
+	if loaded.ChatLimit <= 0 || loaded.ChatWindow <= 0 {
+		loaded.ChatLimit, loaded.ChatWindow = defaultSettings().ChatLimit, defaultSettings().ChatWindow
+	}
+	if loaded.DiceMultiplier <= 0 {
+		loaded.DiceMultiplier = defaultDiceMultiplier
+	}
+	if err := validateChannels(loaded.Channels); err != nil {
+		a.AddLogMsg("Chat channels reset: " + err.Error())
+		loaded.Channels = defaultChannels()
+	}
+	if loaded.Channels == nil {
+		loaded.Channels = map[string]string{}
+	}
+	for kind, channel := range defaultChannels() {
+		if loaded.Channels[kind] == "" {
+			loaded.Channels[kind] = channel
+		}
+	}
+	if loaded.Language == "" {
+		loaded.Language = defaultLanguage
+	}
+
+	settingsMu.Lock()
+	settings = loaded
+	settingsMu.Unlock()
+	table.SetLayout(loaded.BoothLayout)
+	return &loaded
+}
+
+func (a *App) SaveSettings(s *BotSettings) {
+	if s.MaxRisks < 0 || s.MaxBalance < 0 || s.ChoiceTimeout < 0 || s.DiceRetries < 0 {
+		a.AddLogMsg("Settings not saved: limits can't be negative")
+		return
+	}
+	if s.ChoiceTimeoutAction != "refund" && s.ChoiceTimeoutAction != "end" {
+		a.AddLogMsg("Settings not saved: choice timeout action must be refund or end")
+		return
+	}
+	if s.ChatLimit <= 0 || s.ChatWindow <= 0 {
+		a.AddLogMsg("Settings not saved: chat limit and window must be positive")
+		return
+	}
+	if s.DiceMultiplier <= 0 {
+		a.AddLogMsg("Settings not saved: dice multiplier must be positive")
+		return
+	}
+	if s.VoidPolicy != "replay" && s.VoidPolicy != "refund" {
+		a.AddLogMsg("Settings not saved: void policy must be replay or refund")
+		return
+	}
+	if s.InterferencePolicy != "flag" && s.InterferencePolicy != "pause" && s.InterferencePolicy != "void" {
+		a.AddLogMsg("Settings not saved: interference policy must be flag, pause or void")
+		return
+	}
+	if !validTimingProfile(s.TimingProfile) {
+		a.AddLogMsg("Settings not saved: timing profile must be fast, natural or cautious")
+		return
+	}
+	if !validLayout(s.BoothLayout) {
+		a.AddLogMsg("Settings not saved: booth layout must be row, arc or pentagon")
+		return
+	}
+	if err := validateChannels(s.Channels); err != nil {
+		a.AddLogMsg("Settings not saved: " + err.Error())
+		return
+	}
+	if !knownLanguage(s.Language) {
+		a.AddLogMsg("Settings not saved: language must be one of " + languageList())
+		return
+	}
+
+	file, err := os.Create(getSettingsFilePath())
+	if err != nil {
+		a.AddLogMsg("Error creating settings file: " + err.Error())
+		return
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(s); err != nil {
+		a.AddLogMsg("Error encoding settings file: " + err.Error())
+		return
+	}
+
+	settingsMu.Lock()
+	settings = *s
+	settingsMu.Unlock()
+	table.SetLayout(s.BoothLayout)
+	a.AddLogMsg("Settings saved successfully")
+}
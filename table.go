@@ -0,0 +1,620 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TablePhase is the step a table is in while a game is played on it
+type TablePhase int
+
+const (
+	PhaseIdle TablePhase = iota
+	PhaseRolling
+	PhaseEvaluating
+	PhaseSettling
+	PhaseClosing
+)
+
+func (p TablePhase) String() string {
+	switch p {
+	case PhaseIdle:
+		return "idle"
+	case PhaseRolling:
+		return "rolling"
+	case PhaseEvaluating:
+		return "evaluating"
+	case PhaseSettling:
+		return "settling"
+	case PhaseClosing:
+		return "closing"
+	default:
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+}
+
+// Allowed phase changes. Everything else is rejected by transition.
+var tableTransitions = map[TablePhase][]TablePhase{
+	PhaseIdle:       {PhaseRolling, PhaseClosing},
+	PhaseRolling:    {PhaseEvaluating, PhaseIdle},
+	PhaseEvaluating: {PhaseRolling, PhaseSettling, PhaseIdle},
+	PhaseSettling:   {PhaseIdle},
+	PhaseClosing:    {PhaseIdle},
+}
+
+// Table owns the booth dice, the game being played on them and its phase.
+// All dice state goes through it so packet handlers and games never race.
+type Table struct {
+	mu     sync.Mutex
+	dice   []*Dice
+	layout string
+	game   string
+	phase  TablePhase
+	// Counts the games begun, to tell their results apart
+	round int
+
+	// Dice still expected to report a value for the current roll, by id
+	pending     map[int]*Dice
+	resultsDone chan struct{}
+
+	// Results owed by throws and closes the bot sent, by dice id. Any other
+	// value change during a game is interference.
+	expected map[int]int
+	// Dice seen rolling during a game without the bot throwing them. Their
+	// next value is interference whatever face it lands on.
+	foreign map[int]bool
+
+	// Closed when the game is voided, with the reason
+	voided     chan struct{}
+	voidReason string
+
+	// Set while the game is paused, closed on resume
+	paused      chan struct{}
+	pauseReason string
+
+	// Last announced result, for :verify
+	lastResult string
+}
+
+// Number of dice the built-in games use
+const boothSize = 5
+
+// Most dice a booth can have: the tiles around the dealer
+const maxBoothDice = 8
+
+var table = &Table{layout: LayoutRow}
+
+func (t *Table) transition(to TablePhase) error {
+	for _, allowed := range tableTransitions[t.phase] {
+		if allowed == to {
+			t.phase = to
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid table transition %s -> %s", t.phase, to)
+}
+
+// Phase returns the current phase
+func (t *Table) Phase() TablePhase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.phase
+}
+
+// Game returns the game being played, empty when idle
+func (t *Table) Game() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.game
+}
+
+// Round returns the number of the game being (or last) played
+func (t *Table) Round() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.round
+}
+
+// Busy reports whether a game or close is running
+func (t *Table) Busy() bool {
+	return t.Phase() != PhaseIdle
+}
+
+// Begin starts a game on an idle table
+func (t *Table) Begin(game string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase != PhaseIdle {
+		return fmt.Errorf("can't start %s: table is %s (%s)", game, t.phase, t.game)
+	}
+	if err := t.transition(PhaseRolling); err != nil {
+		return err
+	}
+	t.game = game
+	t.round++
+	t.expected = map[int]int{}
+	t.foreign = map[int]bool{}
+	t.voided = make(chan struct{})
+	t.voidReason = ""
+	return nil
+}
+
+// Void stops the game being played. Rolls in progress return an error and
+// the game goes through its void path.
+func (t *Table) Void(reason string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase == PhaseIdle || t.phase == PhaseClosing || t.voided == nil {
+		return fmt.Errorf("no game to void")
+	}
+	if t.voidReason != "" {
+		return fmt.Errorf("game already voided: %s", t.voidReason)
+	}
+	t.voidReason = reason
+	close(t.voided)
+	t.resume()
+	return nil
+}
+
+// Pause holds the game before its next throw until Resume or Void
+func (t *Table) Pause(reason string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase == PhaseIdle || t.phase == PhaseClosing {
+		return fmt.Errorf("no game to pause")
+	}
+	if t.paused != nil {
+		return fmt.Errorf("game already paused: %s", t.pauseReason)
+	}
+	t.paused = make(chan struct{})
+	t.pauseReason = reason
+	return nil
+}
+
+// Resume lets a paused game carry on
+func (t *Table) Resume() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.paused == nil {
+		return fmt.Errorf("game isn't paused")
+	}
+	t.resume()
+	return nil
+}
+
+func (t *Table) resume() {
+	if t.paused != nil {
+		close(t.paused)
+		t.paused = nil
+		t.pauseReason = ""
+	}
+}
+
+// WaitIfPaused blocks while the game is paused. It returns the void reason
+// when the game was voided instead of resumed.
+func (t *Table) WaitIfPaused() error {
+	t.mu.Lock()
+	paused := t.paused
+	t.mu.Unlock()
+	if paused != nil {
+		<-paused
+	}
+	if reason := t.Voided(); reason != "" {
+		return errors.New(reason)
+	}
+	return nil
+}
+
+// Voided returns the void reason, empty when the game wasn't voided
+func (t *Table) Voided() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.voidReason
+}
+
+// BeginClose starts closing the booth on an idle table
+func (t *Table) BeginClose() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase != PhaseIdle {
+		return fmt.Errorf("can't close dice: table is %s (%s)", t.phase, t.game)
+	}
+	if err := t.transition(PhaseClosing); err != nil {
+		return err
+	}
+	t.game = "close"
+	return nil
+}
+
+// Settle moves an evaluated game to settling, before results are announced
+func (t *Table) Settle() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.transition(PhaseSettling)
+}
+
+// Finish returns the table to idle, whatever the game ended on
+func (t *Table) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase == PhaseIdle {
+		return
+	}
+	if err := t.transition(PhaseIdle); err != nil {
+		log.Println(err)
+		return
+	}
+	t.game = ""
+	t.voided = nil
+	t.resume()
+	t.clearPending()
+}
+
+// Reset forgets the booth and forces the table back to idle
+func (t *Table) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dice = []*Dice{}
+	t.phase = PhaseIdle
+	t.game = ""
+	t.voided = nil
+	t.resume()
+	t.clearPending()
+}
+
+func (t *Table) clearPending() {
+	for _, dice := range t.dice {
+		dice.IsRolling = false
+	}
+	t.pending = nil
+	t.resultsDone = nil
+}
+
+// SetBooth replaces the booth dice on an idle table
+func (t *Table) SetBooth(dices []*Dice) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase != PhaseIdle {
+		return fmt.Errorf("table is %s (%s)", t.phase, t.game)
+	}
+	t.dice = dices
+	t.sortDice()
+	return nil
+}
+
+// SetLayout changes the booth layout and sorts the dice again. The order is
+// left alone while a game is rolling.
+func (t *Table) SetLayout(layout string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.layout = layout
+	if t.phase == PhaseIdle {
+		t.sortDice()
+	}
+}
+
+func (t *Table) sortDice() {
+	if !sortByLayout(t.dice, t.layout) {
+		log.Println("Booth dice positions unknown, keeping the order they were added")
+	}
+}
+
+// Register adds a booth dice seen being thrown or turned off by the dealer.
+// It returns true when the dice is new to the booth.
+func (t *Table) Register(dice *Dice) (added bool, count int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, d := range t.dice {
+		if d.ID == dice.ID {
+			return false, len(t.dice)
+		}
+	}
+	if len(t.dice) >= maxBoothDice {
+		return false, len(t.dice)
+	}
+	t.dice = append(t.dice, dice)
+	if t.phase == PhaseIdle {
+		t.sortDice()
+	}
+	return true, len(t.dice)
+}
+
+// MoveDice updates the tile of a booth dice that was moved in the room.
+// It returns true when a booth dice changed tile.
+func (t *Table) MoveDice(id int, x int, y int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, dice := range t.dice {
+		if dice.ID == id {
+			if dice.Placed && dice.X == x && dice.Y == y {
+				return false
+			}
+			dice.X, dice.Y, dice.Placed = x, y, true
+			if t.phase == PhaseIdle {
+				t.sortDice()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve maps booth positions to dice indices for the current booth
+func (t *Table) Resolve(positions []string) ([]int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return resolvePositions(positions, len(t.dice))
+}
+
+// What a DICE_VALUE meant for the table
+type valueUpdate int
+
+const (
+	// Not a booth dice
+	valueIgnored valueUpdate = iota
+	// Booth dice changed while no game was running
+	valueIdle
+	// Result of a throw or close sent by the bot
+	valueExpected
+	// Booth dice changed during a game without the bot throwing it
+	valueInterference
+)
+
+// SetValue stores a DICE_VALUE result and reports whether the bot was
+// expecting it
+func (t *Table) SetValue(id int, value int) (valueUpdate, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, dice := range t.dice {
+		if dice.ID != id {
+			continue
+		}
+		previous := dice.Value
+
+		update := valueIdle
+		switch {
+		case t.phase == PhaseIdle || t.phase == PhaseClosing:
+		case t.foreign[id]:
+			delete(t.foreign, id)
+			update = valueInterference
+		case t.expected[id] > 0:
+			t.expected[id]--
+			update = valueExpected
+		case value != previous:
+			update = valueInterference
+		default:
+			// Same value again, nothing changed on the booth
+			update = valueExpected
+		}
+
+		if _, ok := t.pending[id]; ok && update == valueExpected && t.phase == PhaseRolling {
+			dice.IsRolling = false
+			delete(t.pending, id)
+			if len(t.pending) == 0 && t.resultsDone != nil {
+				close(t.resultsDone)
+				t.resultsDone = nil
+			}
+		}
+		dice.Value = value
+		dice.IsClosed = value == 0
+		return update, previous
+	}
+	return valueIgnored, 0
+}
+
+// Rolling notes that a booth dice started rolling. It returns true when the
+// roll is someone else's: a game is running and the bot owes no result for
+// the dice.
+func (t *Table) Rolling(id int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phase == PhaseIdle || t.phase == PhaseClosing || t.expected[id] > 0 {
+		return false
+	}
+	for _, dice := range t.dice {
+		if dice.ID == id {
+			t.foreign[id] = true
+			return true
+		}
+	}
+	return false
+}
+
+// expect notes that the bot threw or closed a dice and a result will follow
+func (t *Table) expect(id int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.expected == nil {
+		t.expected = map[int]int{}
+	}
+	t.expected[id]++
+}
+
+// HasDice reports whether a dice is part of the booth
+func (t *Table) HasDice(id int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, dice := range t.dice {
+		if dice.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of booth dice
+func (t *Table) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.dice)
+}
+
+// Snapshot returns a copy of the booth dice
+func (t *Table) Snapshot() []*Dice {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dices := make([]*Dice, 0, len(t.dice))
+	for _, dice := range t.dice {
+		copied := *dice
+		dices = append(dices, &copied)
+	}
+	return dices
+}
+
+// Values returns the values of the dice at the given indices
+func (t *Table) Values(indices []int) []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	values := make([]int, 0, len(indices))
+	for _, index := range indices {
+		if index < len(t.dice) {
+			values = append(values, t.dice[index].Value)
+		}
+	}
+	return values
+}
+
+// Sum returns the sum of the dice at the given indices
+func (t *Table) Sum(indices []int) int {
+	return sumHandInt(t.Values(indices))
+}
+
+func (t *Table) LastResult() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastResult
+}
+
+func (t *Table) SetLastResult(result string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastResult = result
+}
+
+// errNoResult is returned by RollDice when dice never reported a value
+type errNoResult struct {
+	ids []int
+}
+
+func (e errNoResult) Error() string {
+	parts := make([]string, 0, len(e.ids))
+	for _, id := range e.ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return "no result from dice " + strings.Join(parts, ", ")
+}
+
+// RollDice throws the dice at the given indices and waits for their results.
+// Dice that don't answer within timeout are thrown again, up to retries
+// times, before giving up on them. The table is rolling until the results
+// are in, then evaluating.
+func (t *Table) RollDice(indices []int, timeout time.Duration, retries int) error {
+	if err := t.WaitIfPaused(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	if t.phase != PhaseRolling {
+		if err := t.transition(PhaseRolling); err != nil {
+			t.mu.Unlock()
+			return err
+		}
+	}
+
+	dices := make([]*Dice, 0, len(indices))
+	for _, index := range indices {
+		if index >= len(t.dice) {
+			t.mu.Unlock()
+			return fmt.Errorf("not enough dice to roll")
+		}
+		dices = append(dices, t.dice[index])
+	}
+	t.pending = map[int]*Dice{}
+	for _, dice := range dices {
+		dice.IsRolling = true
+		dice.IsClosed = false
+		t.pending[dice.ID] = dice
+	}
+	done := make(chan struct{})
+	t.resultsDone = done
+	voided := t.voided
+	t.mu.Unlock()
+
+	timing := activeTiming()
+	for _, dice := range dices {
+		t.expect(dice.ID)
+		dice.Roll()
+		timing.ThrowSpacing.Sleep()
+	}
+	timing.ResultSettle.Sleep()
+
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-done:
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return t.transition(PhaseEvaluating)
+		case <-voided:
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.clearPending()
+			return errors.New(t.voidReason)
+		case <-time.After(timeout):
+		}
+
+		missing := t.pendingDice()
+		if len(missing) == 0 {
+			// The last result came in as the wait ran out
+			continue
+		}
+		if attempt >= retries {
+			ids := make([]int, 0, len(missing))
+			for _, dice := range missing {
+				ids = append(ids, dice.ID)
+			}
+			t.mu.Lock()
+			t.clearPending()
+			t.mu.Unlock()
+			return errNoResult{ids: ids}
+		}
+
+		// Throw only the dice that haven't answered
+		for _, dice := range missing {
+			log.Printf("Dice %d didn't report a value, throwing it again (%d/%d)", dice.ID, attempt+1, retries)
+			t.expect(dice.ID)
+			dice.Roll()
+			timing.ThrowSpacing.Sleep()
+		}
+	}
+}
+
+// pendingDice returns the dice still waiting for a result, in booth order
+func (t *Table) pendingDice() []*Dice {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	missing := []*Dice{}
+	for _, dice := range t.dice {
+		if _, ok := t.pending[dice.ID]; ok {
+			missing = append(missing, dice)
+		}
+	}
+	return missing
+}
+
+// CloseAll turns off every booth dice. It doesn't change the phase, games
+// close the booth as part of their roll.
+func (t *Table) CloseAll() {
+	timing := activeTiming()
+	for _, dice := range t.Snapshot() {
+		if !dice.IsClosed {
+			t.expect(dice.ID)
+		}
+		dice.Close()
+		timing.CloseSpacing.Sleep()
+	}
+}
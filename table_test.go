@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestSetValueInterference(t *testing.T) {
+	tests := []struct {
+		name    string
+		expect  bool
+		rolling bool
+		value   int
+		want    valueUpdate
+	}{
+		{name: "own throw", expect: true, rolling: true, value: 3, want: valueExpected},
+		{name: "foreign throw, new face", rolling: true, value: 5, want: valueInterference},
+		{name: "foreign throw, same face", rolling: true, value: 3, want: valueInterference},
+		{name: "same value resent", value: 3, want: valueExpected},
+		{name: "value changed without a roll", value: 4, want: valueInterference},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &Table{layout: LayoutRow, dice: []*Dice{{ID: 7, Value: 3}}}
+			if err := tb.Begin("test"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.expect {
+				tb.expect(7)
+			}
+			if tt.rolling {
+				if foreign := tb.Rolling(7); foreign == tt.expect {
+					t.Fatalf("Rolling() = %t, want %t", foreign, !tt.expect)
+				}
+			}
+			if got, _ := tb.SetValue(7, tt.value); got != tt.want {
+				t.Errorf("SetValue() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollingOnIdleTable(t *testing.T) {
+	tb := &Table{layout: LayoutRow, dice: []*Dice{{ID: 7, Value: 3}}}
+	if tb.Rolling(7) {
+		t.Error("Rolling() = true with no game running")
+	}
+	if got, _ := tb.SetValue(7, 3); got != valueIdle {
+		t.Errorf("SetValue() = %d, want %d", got, valueIdle)
+	}
+}
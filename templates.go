@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Message template keys, one for every line the bot can say in chat
+const (
+	msgHandPlayer      = "hand_player"
+	msgHandDealer      = "hand_dealer"
+	msgHandSolo        = "hand_solo"
+	msgPlayerWins      = "result_player_wins"
+	msgDealerWins      = "result_dealer_wins"
+	msgPlayerBusts     = "result_player_bust"
+	msgDealerBusts     = "result_dealer_bust"
+	msgTie             = "result_tie"
+	msgSessionStarted  = "session_started"
+	msgNotReady        = "session_denied_not_ready"
+	msgCantCover       = "session_denied_cover"
+	msgSessionWin      = "session_win"
+	msgSessionPush     = "session_push"
+	msgChooseGame      = "choose_game"
+	msgChoiceReminder  = "choice_reminder"
+	msgChoiceExpired   = "choice_expired"
+	msgRiskDenied      = "risk_denied"
+	msgRisk            = "risk"
+	msgRollVoid        = "void_roll"
+	msgVoidReplay      = "void_replay"
+	msgVoidRefund      = "void_refund"
+	msgRefundDone      = "refund_done"
+	msgCashoutDone     = "cashout_done"
+	msgLanguageSet     = "language_set"
+	msgLanguageUnknown = "language_unknown"
+)
+
+// msgVars are the values of a message's placeholders
+type msgVars map[string]string
+
+// templateDef is a message the bot can say, its default text and the
+// placeholders it may use
+type templateDef struct {
+	key          string
+	description  string
+	text         string
+	placeholders []string
+}
+
+var templateDefs = []templateDef{
+	{msgHandPlayer, "Player's hand", "Player has {player_hand} {dice}", []string{"game", "player", "player_hand", "dice"}},
+	{msgHandDealer, "Dealer's hand", "Dealer has {dealer_hand} {dice}", []string{"game", "player", "dealer_hand", "dice"}},
+	{msgHandSolo, "Hand rolled without a bet", "{hand}", []string{"game", "hand", "dice"}},
+	{msgPlayerWins, "Player's hand beats the dealer's", "{player_hand} beats {dealer_hand}, Player wins.", []string{"game", "player", "player_hand", "dealer_hand"}},
+	{msgDealerWins, "Dealer's hand beats the player's", "{dealer_hand} beats {player_hand}, Dealer wins.", []string{"game", "player", "player_hand", "dealer_hand"}},
+	{msgPlayerBusts, "Player goes over the limit", "Player busts, Dealer wins.", []string{"game", "player", "player_hand", "dealer_hand"}},
+	{msgDealerBusts, "Dealer goes over the limit", "Dealer busts, Player wins.", []string{"game", "player", "player_hand", "dealer_hand"}},
+	{msgTie, "Hands are equal", "Tie game.", []string{"game", "player", "player_hand", "dealer_hand"}},
+	{msgSessionStarted, "Bet traded in", "{player} bet {bet} credits ({items}). Choose game: {games}", []string{"player", "bet", "items", "games", "languages"}},
+	{msgNotReady, "Bet refused, inventory not loaded", "Inventory not ready. Please retry trade.", []string{"player", "bet", "items"}},
+	{msgCantCover, "Bet refused, payout not covered", "Can't cover payout for {items} ({needed} credits needed).", []string{"player", "bet", "items", "needed"}},
+	{msgSessionWin, "Player won the round", "{player} now has {balance} credits. Use :risk or :cashout.", []string{"game", "player", "balance"}},
+	{msgSessionPush, "Round tied, bet stays in play", "Push, {player} keeps {bet} credits in play. Choose game: {games}", []string{"game", "player", "bet", "games"}},
+	{msgChooseGame, "Player has to choose again", "{player}, choose game: {games}", []string{"player", "games"}},
+	{msgChoiceReminder, "Game choice reminder", "{player}, choose game: {games} ({seconds}s left)", []string{"player", "games", "seconds"}},
+	{msgChoiceExpired, "Game never chosen", "{player} never chose a game, session ended.", []string{"player"}},
+	{msgRiskDenied, "Risk refused", "{player} can't risk: {reason}. Use :cashout.", []string{"player", "balance", "reason"}},
+	{msgRisk, "Balance put back in play", "{player} risks {bet} credits for {needed}. Choose game: {games}", []string{"player", "bet", "needed", "games"}},
+	{msgRollVoid, "Roll without a bet voided", "{game} roll void ({reason}).", []string{"game", "reason"}},
+	{msgVoidReplay, "Round voided, replaying", "{game} round void ({reason}), replaying for {player}.", []string{"game", "reason", "player", "bet"}},
+	{msgVoidRefund, "Round voided, refunding", "{game} round void ({reason}), refunding {bet} credits to {player}.", []string{"game", "reason", "player", "bet"}},
+	{msgRefundDone, "Bet refunded by trade", "Returned {items} to {player}.", []string{"player", "count", "items"}},
+	{msgCashoutDone, "Balance paid by trade", "Paid {items} to {player}. Thanks for playing!", []string{"player", "count", "items"}},
+	{msgLanguageSet, "Player chose a language", "{player}, messages are now in {language}.", []string{"player", "language"}},
+	{msgLanguageUnknown, "Player asked for a missing language", "{player}, languages: {languages}", []string{"player", "languages"}},
+}
+
+// Values shown in the GUI preview
+var previewVars = msgVars{
+	"game":        "Poker",
+	"player":      "Bob",
+	"player_hand": "Full House",
+	"dealer_hand": "Two Pair",
+	"hand":        "Full House",
+	"dice":        "[5,5,5,2,2]",
+	"balance":     "4",
+	"bet":         "2",
+	"items":       "2 duck, 1 throne",
+	"needed":      "4",
+	"count":       "40",
+	"games":       ":pkr, :tri, :21, :13",
+	"reason":      "max 3 risks in a row reached",
+	"seconds":     "60",
+	"language":    "English",
+	"languages":   "en, es, nl, pt",
+}
+
+// Longest message the hotel lets through in one chat line
+const maxMessageLength = 100
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// MessageTemplate is one message as edited in the GUI
+type MessageTemplate struct {
+	Key          string   `json:"key"`
+	Description  string   `json:"description"`
+	Text         string   `json:"text"`
+	Placeholders []string `json:"placeholders"`
+}
+
+// TemplatePreview is a template rendered with sample values
+type TemplatePreview struct {
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+// Dealer's edited templates by key, the rest use their default text
+var (
+	messageTemplates   = map[string]string{}
+	messageTemplatesMu sync.RWMutex
+)
+
+func getTemplatesFilePath() string {
+	return configFilePath("message_templates.json")
+}
+
+func findTemplateDef(key string) (templateDef, bool) {
+	for _, def := range templateDefs {
+		if def.key == key {
+			return def, true
+		}
+	}
+	return templateDef{}, false
+}
+
+// renderMessage fills in a message template in the chat language. Keys the
+// language pack leaves out use the dealer's template.
+func renderMessage(key string, vars msgVars) string {
+	if text, ok := translate(chatLanguage(), key); ok {
+		return fillTemplate(text, vars)
+	}
+	messageTemplatesMu.RLock()
+	text, ok := messageTemplates[key]
+	messageTemplatesMu.RUnlock()
+	if !ok {
+		def, _ := findTemplateDef(key)
+		text = def.text
+	}
+	return fillTemplate(text, vars)
+}
+
+func fillTemplate(text string, vars msgVars) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return vars[match[1:len(match)-1]]
+	})
+}
+
+// validateTemplate checks a template only uses its message's placeholders
+// and fits in a chat line with the sample values
+func validateTemplate(key string, text string) error {
+	def, ok := findTemplateDef(key)
+	if !ok {
+		return fmt.Errorf("unknown message %q", key)
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%s can't be empty", key)
+	}
+	if strings.Count(text, "{") != strings.Count(text, "}") {
+		return fmt.Errorf("%s has an unclosed placeholder", key)
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		allowed := false
+		for _, p := range def.placeholders {
+			if p == match[1] {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%s can't use {%s}, it has %s", key, match[1], formatPlaceholders(def.placeholders))
+		}
+	}
+	if rendered := fillTemplate(text, previewVars); len(rendered) > maxMessageLength {
+		return fmt.Errorf("%s is %d characters with sample values, the limit is %d", key, len(rendered), maxMessageLength)
+	}
+	return nil
+}
+
+func formatPlaceholders(placeholders []string) string {
+	parts := make([]string, 0, len(placeholders))
+	for _, p := range placeholders {
+		parts = append(parts, "{"+p+"}")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (a *App) LoadTemplates() []MessageTemplate {
+	loaded := map[string]string{}
+	file, err := os.Open(getTemplatesFilePath())
+	if err == nil {
+		defer file.Close()
+		if err := json.NewDecoder(file).Decode(&loaded); err != nil {
+			a.AddLogMsg("Error decoding message templates file: " + err.Error())
+			loaded = map[string]string{}
+		}
+	}
+	for key, text := range loaded {
+		if err := validateTemplate(key, text); err != nil {
+			a.AddLogMsg("Message template ignored: " + err.Error())
+			delete(loaded, key)
+		}
+	}
+
+	messageTemplatesMu.Lock()
+	messageTemplates = loaded
+	messageTemplatesMu.Unlock()
+	return currentTemplates()
+}
+
+// currentTemplates lists every message with its active text
+func currentTemplates() []MessageTemplate {
+	messageTemplatesMu.RLock()
+	defer messageTemplatesMu.RUnlock()
+	templates := make([]MessageTemplate, 0, len(templateDefs))
+	for _, def := range templateDefs {
+		text, ok := messageTemplates[def.key]
+		if !ok {
+			text = def.text
+		}
+		templates = append(templates, MessageTemplate{
+			Key:          def.key,
+			Description:  def.description,
+			Text:         text,
+			Placeholders: def.placeholders,
+		})
+	}
+	return templates
+}
+
+func (a *App) SaveTemplates(templates []MessageTemplate) {
+	saved := map[string]string{}
+	for _, t := range templates {
+		if err := validateTemplate(t.Key, t.Text); err != nil {
+			a.AddLogMsg("Message templates not saved: " + err.Error())
+			return
+		}
+		// Only keep what differs from the default
+		if def, _ := findTemplateDef(t.Key); t.Text != def.text {
+			saved[t.Key] = t.Text
+		}
+	}
+
+	file, err := os.Create(getTemplatesFilePath())
+	if err != nil {
+		a.AddLogMsg("Error creating message templates file: " + err.Error())
+		return
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(saved); err != nil {
+		a.AddLogMsg("Error encoding message templates file: " + err.Error())
+		return
+	}
+
+	messageTemplatesMu.Lock()
+	messageTemplates = saved
+	messageTemplatesMu.Unlock()
+	a.AddLogMsg("Message templates saved successfully")
+}
+
+// PreviewTemplate renders a template being edited with sample values
+func (a *App) PreviewTemplate(key string, text string) TemplatePreview {
+	if err := validateTemplate(key, text); err != nil {
+		return TemplatePreview{Error: err.Error()}
+	}
+	return TemplatePreview{Text: fillTemplate(text, previewVars)}
+}
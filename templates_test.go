@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		text    string
+		wantErr string
+	}{
+		{name: "default text", key: msgChooseGame, text: "{player}, choose game: {games}"},
+		{name: "reworded", key: msgChooseGame, text: "Pick one {player}: {games}"},
+		{name: "no placeholders", key: msgChoiceExpired, text: "Session over."},
+		{name: "unknown message", key: "nope", text: "hi", wantErr: "unknown message"},
+		{name: "empty", key: msgChooseGame, text: "  ", wantErr: "can't be empty"},
+		{name: "unclosed placeholder", key: msgChooseGame, text: "{player, choose", wantErr: "unclosed placeholder"},
+		{name: "placeholder of another message", key: msgChoiceExpired, text: "{player} has {balance}", wantErr: "can't use {balance}"},
+		{name: "too long with sample values", key: msgChooseGame, text: "{player} " + strings.Repeat("x", maxMessageLength), wantErr: "the limit is"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTemplate(tt.key, tt.text)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("validateTemplate() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("validateTemplate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultTemplatesAreValid(t *testing.T) {
+	for _, def := range templateDefs {
+		if err := validateTemplate(def.key, def.text); err != nil {
+			t.Errorf("default %s: %v", def.key, err)
+		}
+	}
+}
+
+func TestFillTemplate(t *testing.T) {
+	got := fillTemplate("{player}, choose game: {games} {missing}", msgVars{"player": "Bob", "games": ":21, :13"})
+	if want := "Bob, choose game: :21, :13 "; got != want {
+		t.Errorf("fillTemplate() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// Delay is a random wait between Min and Max milliseconds
+type Delay struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// Duration picks a wait from the delay's range
+func (d Delay) Duration() time.Duration {
+	ms := d.Min
+	if d.Max > d.Min {
+		ms += rand.Intn(d.Max - d.Min + 1)
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func (d Delay) Sleep() {
+	time.Sleep(d.Duration())
+}
+
+// TimingProfile sets how fast the dealer throws and talks. Each wait is
+// picked at random from its range so the bot doesn't keep a fixed rhythm.
+type TimingProfile struct {
+	Name string `json:"name"`
+	// Between two dice throws
+	ThrowSpacing Delay `json:"throw_spacing"`
+	// Between two dice being turned off
+	CloseSpacing Delay `json:"close_spacing"`
+	// After the last throw, before waiting for the results
+	ResultSettle Delay `json:"result_settle"`
+	// Before throwing the last hit dice again
+	HitPause Delay `json:"hit_pause"`
+	// Before a result is logged and announced
+	PreAnnounce Delay `json:"pre_announce"`
+	// Before a chat message is sent, as if typing it
+	ChatTyping Delay `json:"chat_typing"`
+	// Between the player's and the dealer's hand
+	PhasePause Delay `json:"phase_pause"`
+}
+
+// Profile names, in the order the GUI shows them
+const (
+	TimingFast     = "fast"
+	TimingNatural  = "natural"
+	TimingCautious = "cautious"
+)
+
+var (
+	timingProfiles   = defaultTimingProfiles()
+	timingProfilesMu sync.RWMutex
+)
+
+func defaultTimingProfiles() []TimingProfile {
+	return []TimingProfile{
+		{
+			Name:         TimingFast,
+			ThrowSpacing: Delay{300, 380},
+			CloseSpacing: Delay{300, 350},
+			ResultSettle: Delay{500, 600},
+			HitPause:     Delay{300, 700},
+			PreAnnounce:  Delay{100, 200},
+			ChatTyping:   Delay{100, 250},
+			PhasePause:   Delay{1500, 2000},
+		},
+		{
+			Name:         TimingNatural,
+			ThrowSpacing: Delay{550, 650},
+			CloseSpacing: Delay{550, 600},
+			ResultSettle: Delay{1000, 1000},
+			HitPause:     Delay{500, 1500},
+			PreAnnounce:  Delay{250, 500},
+			ChatTyping:   Delay{250, 500},
+			PhasePause:   Delay{3000, 3000},
+		},
+		{
+			Name:         TimingCautious,
+			ThrowSpacing: Delay{800, 1200},
+			CloseSpacing: Delay{700, 900},
+			ResultSettle: Delay{1200, 1500},
+			HitPause:     Delay{1000, 2500},
+			PreAnnounce:  Delay{500, 1000},
+			ChatTyping:   Delay{500, 1000},
+			PhasePause:   Delay{4000, 6000},
+		},
+	}
+}
+
+func validTimingProfile(name string) bool {
+	return name == TimingFast || name == TimingNatural || name == TimingCautious
+}
+
+func getTimingFilePath() string {
+	return configFilePath("timing_profiles.json")
+}
+
+// activeTiming returns the profile picked in the settings
+func activeTiming() TimingProfile {
+	name := currentSettings().TimingProfile
+	timingProfilesMu.RLock()
+	defer timingProfilesMu.RUnlock()
+	for _, profile := range timingProfiles {
+		if profile.Name == name {
+			return profile
+		}
+	}
+	return defaultTimingProfiles()[1]
+}
+
+func (p TimingProfile) validate() error {
+	if !validTimingProfile(p.Name) {
+		return fmt.Errorf("unknown profile %q", p.Name)
+	}
+	delays := map[string]Delay{
+		"throw spacing": p.ThrowSpacing,
+		"close spacing": p.CloseSpacing,
+		"result settle": p.ResultSettle,
+		"hit pause":     p.HitPause,
+		"pre-announce":  p.PreAnnounce,
+		"chat typing":   p.ChatTyping,
+		"phase pause":   p.PhasePause,
+	}
+	for name, d := range delays {
+		if d.Min < 0 || d.Max < d.Min || d.Max > 60000 {
+			return fmt.Errorf("%s %s must be 0 <= min <= max <= 60000 ms", p.Name, name)
+		}
+	}
+	return nil
+}
+
+func (a *App) LoadTimingProfiles() []TimingProfile {
+	loaded := defaultTimingProfiles()
+
+	file, err := os.Open(getTimingFilePath())
+	if err == nil {
+		defer file.Close()
+		var saved []TimingProfile
+		if err := json.NewDecoder(file).Decode(&saved); err != nil {
+			a.AddLogMsg("Error decoding timing profiles file: " + err.Error())
+		} else {
+			// Profiles missing from the file keep their defaults
+			for _, profile := range saved {
+				for i := range loaded {
+					if loaded[i].Name == profile.Name && profile.validate() == nil {
+						loaded[i] = profile
+					}
+				}
+			}
+		}
+	}
+
+	timingProfilesMu.Lock()
+	timingProfiles = loaded
+	timingProfilesMu.Unlock()
+	return loaded
+}
+
+func (a *App) SaveTimingProfiles(profiles []TimingProfile) {
+	for _, profile := range profiles {
+		if err := profile.validate(); err != nil {
+			a.AddLogMsg("Timing profiles not saved: " + err.Error())
+			return
+		}
+	}
+
+	file, err := os.Create(getTimingFilePath())
+	if err != nil {
+		a.AddLogMsg("Error creating timing profiles file: " + err.Error())
+		return
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(profiles); err != nil {
+		a.AddLogMsg("Error encoding timing profiles file: " + err.Error())
+		return
+	}
+
+	a.LoadTimingProfiles()
+	a.AddLogMsg("Timing profiles saved successfully")
+}
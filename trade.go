@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Item types in a trade offer
+const (
+	itemTypeFloor = "S"
+	itemTypeWall  = "I"
+)
+
+// TradeItem is one item of a trade offer
+type TradeItem struct {
+	// Id of the item in the owner's hand, what TRADE_ADDITEM and cashouts use
+	StripID int
+	Class   string
+	// "S" for floor items, "I" for wall items
+	Type string
+	// Colors of a floor item or props of a wall item
+	Extra string
+}
+
+// TradeOffer is one side of a trade
+type TradeOffer struct {
+	UserName string
+	Accepted bool
+	Items    []TradeItem
+}
+
+// readTradeOpen reads the room index of the user the dealer is trading
+// with from TRADE_OPEN, a decimal string like the one the dealer sends
+func readTradeOpen(data []byte) (int, error) {
+	return strconv.Atoi(strings.TrimSpace(strings.TrimRight(string(data), "\x02")))
+}
+
+// tradeCapture is the bet trade a player opened with the dealer, one at a
+// time. Guarded by mutex like the session.
+type tradeCapture struct {
+	Open bool
+	// Room user the trade was opened with, empty when TRADE_OPEN couldn't
+	// be resolved
+	Partner string
+	// What the partner offers ("2 duck, 1 throne"), its value in credits and
+	// the items by class
+	BetItems  string
+	Bet       int
+	BetCounts map[string]int
+	// The dealer's own items in the trade
+	DealerAdded int
+	OwnIDs      []int
+
+	CanAutoAccept bool
+	AcceptedByBot bool
+}
+
+var betTrade tradeCapture
+
+func tradeIsOpen() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return betTrade.Open
+}
+
+// verifyTradePartner checks the partner's offer comes from the room user
+// the trade was opened with. A trade whose TRADE_OPEN couldn't be resolved
+// to a room user is never accepted.
+func (a *App) verifyTradePartner(name string, opened string) bool {
+	if opened == "" {
+		a.AddLogMsg(fmt.Sprintf("Trade: offer from %s but the trade's user couldn't be resolved, not accepting", name))
+		return false
+	}
+	if !strings.EqualFold(name, opened) {
+		a.AddLogMsg(fmt.Sprintf("Trade: offer from %s but the trade was opened with %s, not accepting", name, opened))
+		return false
+	}
+	return true
+}
+
+// decodeTradeItems reads TRADE_ITEMS. The packet holds both offers, the
+// receiving user's own first:
+//
+//	offer: name, accepted, item count, items
+//	item:  strip id, slot, type, furni id, class, then for floor items
+//	       width, length and colors, for wall items the props
+func decodeTradeItems(data []byte) (own TradeOffer, partner TradeOffer, err error) {
+	r := newWireReader(data)
+	if own, err = readTradeOffer(r); err != nil {
+		return own, partner, fmt.Errorf("own offer: %w", err)
+	}
+	if partner, err = readTradeOffer(r); err != nil {
+		return own, partner, fmt.Errorf("partner offer: %w", err)
+	}
+	return own, partner, nil
+}
+
+func readTradeOffer(r *wireReader) (TradeOffer, error) {
+	var offer TradeOffer
+	var err error
+	if offer.UserName, err = r.readString(); err != nil {
+		return offer, err
+	}
+	if offer.Accepted, err = r.readBool(); err != nil {
+		return offer, err
+	}
+	n, err := r.readInt()
+	if err != nil {
+		return offer, err
+	}
+	if n < 0 || n > r.remaining() {
+		return offer, fmt.Errorf("bad item count %d", n)
+	}
+	offer.Items = make([]TradeItem, 0, n)
+	for i := 0; i < n; i++ {
+		item, err := readTradeItem(r)
+		if err != nil {
+			return offer, fmt.Errorf("item %d: %w", i, err)
+		}
+		offer.Items = append(offer.Items, item)
+	}
+	return offer, nil
+}
+
+func readTradeItem(r *wireReader) (TradeItem, error) {
+	var item TradeItem
+	var err error
+	if item.StripID, err = r.readInt(); err != nil {
+		return item, err
+	}
+	// slot
+	if _, err = r.readInt(); err != nil {
+		return item, err
+	}
+	if item.Type, err = r.readString(); err != nil {
+		return item, err
+	}
+	// furni id
+	if _, err = r.readInt(); err != nil {
+		return item, err
+	}
+	if item.Class, err = r.readString(); err != nil {
+		return item, err
+	}
+
+	switch item.Type {
+	case itemTypeFloor:
+		// width, length
+		for i := 0; i < 2; i++ {
+			if _, err = r.readInt(); err != nil {
+				return item, err
+			}
+		}
+		item.Extra, err = r.readString()
+	case itemTypeWall:
+		item.Extra, err = r.readString()
+	default:
+		err = fmt.Errorf("unknown item type %q", item.Type)
+	}
+	return item, err
+}
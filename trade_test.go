@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TRADE_ITEMS as the server sends it: the dealer's offer, then the partner's.
+// Ints are VL64: H=0 I=1 J=2 PA=4 QA=5 SA=7 ZtD=1234.
+const (
+	tradeOwnDuck = "dealer\x02" + "H" + "I" +
+		"ZtD" + "H" + "S\x02" + "PA" + "duck\x02" + "I" + "I" + "0,0,0\x02"
+	tradePartnerThronePoster = "player\x02" + "I" + "J" +
+		"QA" + "H" + "S\x02" + "I" + "throne\x02" + "J" + "J" + "\x02" +
+		"SA" + "I" + "I\x02" + "J" + "poster\x02" + "2\x02"
+)
+
+func TestDecodeTradeItems(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantOwn     TradeOffer
+		wantPartner TradeOffer
+		wantErr     bool
+	}{
+		{
+			name: "own offer first, then the partner's",
+			data: tradeOwnDuck + tradePartnerThronePoster,
+			wantOwn: TradeOffer{UserName: "dealer", Items: []TradeItem{
+				{StripID: 1234, Class: "duck", Type: itemTypeFloor, Extra: "0,0,0"},
+			}},
+			wantPartner: TradeOffer{UserName: "player", Accepted: true, Items: []TradeItem{
+				{StripID: 5, Class: "throne", Type: itemTypeFloor},
+				{StripID: 7, Class: "poster", Type: itemTypeWall, Extra: "2"},
+			}},
+		},
+		{
+			name:        "empty offers",
+			data:        "dealer\x02HH" + "player\x02HH",
+			wantOwn:     TradeOffer{UserName: "dealer", Items: []TradeItem{}},
+			wantPartner: TradeOffer{UserName: "player", Items: []TradeItem{}},
+		},
+		{
+			name:    "truncated partner offer",
+			data:    tradeOwnDuck + tradePartnerThronePoster[:20],
+			wantErr: true,
+		},
+		{
+			name:    "missing partner offer",
+			data:    tradeOwnDuck,
+			wantErr: true,
+		},
+		{
+			name:    "unknown item type",
+			data:    "dealer\x02HI" + "IHX\x02Iduck\x02" + "player\x02HH",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			own, partner, err := decodeTradeItems([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeTradeItems() = %+v, %+v, want an error", own, partner)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeTradeItems() error = %v", err)
+			}
+			if !reflect.DeepEqual(own, tt.wantOwn) {
+				t.Errorf("own = %+v, want %+v", own, tt.wantOwn)
+			}
+			if !reflect.DeepEqual(partner, tt.wantPartner) {
+				t.Errorf("partner = %+v, want %+v", partner, tt.wantPartner)
+			}
+		})
+	}
+}
+
+func TestReadTradeOpen(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{data: "3", want: 3},
+		{data: "12\x02", want: 12},
+		{data: " 5 ", want: 5},
+		{data: "", wantErr: true},
+		{data: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := readTradeOpen([]byte(tt.data))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("readTradeOpen(%q) = %d, %v, want %d (error %t)", tt.data, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
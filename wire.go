@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errShortPacket = errors.New("packet too short")
+
+// wireReader reads Shockwave wire-encoded values (VL64 ints, 0x02 terminated
+// strings) from a raw packet body.
+type wireReader struct {
+	data []byte
+	pos  int
+}
+
+func newWireReader(data []byte) *wireReader {
+	return &wireReader{data: data}
+}
+
+func (r *wireReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+// readInt decodes a VL64 integer.
+func (r *wireReader) readInt() (int, error) {
+	if r.remaining() < 1 {
+		return 0, errShortPacket
+	}
+	b0 := int(r.data[r.pos] - 64)
+	n := (b0 >> 3) & 7
+	if n < 1 || r.remaining() < n {
+		return 0, errShortPacket
+	}
+
+	value := b0 & 3
+	shift := 2
+	for i := 1; i < n; i++ {
+		value |= int(r.data[r.pos+i]-64) << shift
+		shift += 6
+	}
+	if b0&4 != 0 {
+		value = -value
+	}
+	r.pos += n
+	return value, nil
+}
+
+func (r *wireReader) readBool() (bool, error) {
+	v, err := r.readInt()
+	return v != 0, err
+}
+
+// readString reads up to (and consumes) the next 0x02 terminator.
+func (r *wireReader) readString() (string, error) {
+	if r.remaining() < 1 {
+		return "", errShortPacket
+	}
+	for i := r.pos; i < len(r.data); i++ {
+		if r.data[i] == 0x02 {
+			s := string(r.data[r.pos:i])
+			r.pos = i + 1
+			return s, nil
+		}
+	}
+	return "", errShortPacket
+}
+
+// readIntString reads a string field that carries a decimal number.
+func (r *wireReader) readIntString() (int, error) {
+	s, err := r.readString()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(s)
+}
+
+// decodeB64 decodes a B64 integer, 6 bits per byte, most significant first.
+func decodeB64(s string) (int, error) {
+	if s == "" {
+		return 0, errShortPacket
+	}
+	value := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '@' || s[i] > 0x7f {
+			return 0, errors.New("bad B64 byte")
+		}
+		value = value<<6 | int(s[i]-64)
+	}
+	return value, nil
+}